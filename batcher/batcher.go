@@ -0,0 +1,267 @@
+// Package batcher buffers rows in memory and writes them to a Sink in batches.
+package batcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/OliverSchlueter/goutils/sloki"
+)
+
+var (
+	ErrClosed = errors.New("batcher is closed")
+)
+
+// Sink is the destination of the batches, e.g. a database table.
+type Sink[T any] interface {
+	Insert(ctx context.Context, rows []T) error
+}
+
+type Batcher[T any] struct {
+	sink          Sink[T]
+	batchSize     int
+	flushInterval time.Duration
+	maxRetries    int
+	retryBackoff  time.Duration
+	isRetryable   func(err error) bool
+	onError       func(rows []T, err error)
+
+	rows      chan T
+	flushReqs chan chan error
+	stop      chan struct{}
+	done      chan struct{}
+	// ctx is passed to the sink and canceled when Close gives up waiting
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// mu guards closed, so no Add can start sending once Close has begun waiting for the pending ones
+	mu       sync.RWMutex
+	closed   bool
+	adding   sync.WaitGroup
+	closeErr error
+}
+
+type Configuration[T any] struct {
+	Sink Sink[T]
+	// BatchSize is the number of rows after which a batch is flushed. Defaults to 1000.
+	BatchSize int
+	// FlushInterval is the maximum time rows are buffered before they are flushed. Defaults to 1 second.
+	FlushInterval time.Duration
+	// BufferSize is the number of rows that can be queued before Add blocks. Defaults to 10 * BatchSize.
+	BufferSize int
+	// MaxRetries is the number of retries of a failed insert. Defaults to 3, negative values disable retries.
+	MaxRetries int
+	// RetryBackoff is the wait time before the first retry, it doubles with every retry. Defaults to 100 milliseconds.
+	RetryBackoff time.Duration
+	// IsRetryable decides whether a failed insert is retried. Defaults to retrying everything except context errors.
+	IsRetryable func(err error) bool
+	// OnError is called with the dropped rows when a background flush failed after all retries.
+	OnError func(rows []T, err error)
+}
+
+// New creates a Batcher and starts its background flushing.
+// Close must be called to flush the remaining rows and stop the background goroutine.
+func New[T any](cfg Configuration[T]) *Batcher[T] {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1000
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = 10 * cfg.BatchSize
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 3
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = 100 * time.Millisecond
+	}
+	if cfg.IsRetryable == nil {
+		cfg.IsRetryable = IsRetryable
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &Batcher[T]{
+		sink:          cfg.Sink,
+		batchSize:     cfg.BatchSize,
+		flushInterval: cfg.FlushInterval,
+		maxRetries:    max(cfg.MaxRetries, 0),
+		retryBackoff:  cfg.RetryBackoff,
+		isRetryable:   cfg.IsRetryable,
+		onError:       cfg.OnError,
+		rows:          make(chan T, cfg.BufferSize),
+		flushReqs:     make(chan chan error),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+
+	go b.run()
+
+	return b
+}
+
+// IsRetryable reports whether err is worth retrying, which is the case for all errors except context errors.
+func IsRetryable(err error) bool {
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// Add queues a row for insertion.
+// If the buffer is full, it blocks until there is space again, ctx is done or the batcher is closed.
+func (b *Batcher[T]) Add(ctx context.Context, row T) error {
+	b.mu.RLock()
+	if b.closed {
+		b.mu.RUnlock()
+		return ErrClosed
+	}
+	b.adding.Add(1)
+	b.mu.RUnlock()
+	defer b.adding.Done()
+
+	select {
+	case b.rows <- row:
+		return nil
+	case <-b.stop:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Flush inserts all rows that were added before the call and returns the error of the insert.
+func (b *Batcher[T]) Flush(ctx context.Context) error {
+	reply := make(chan error, 1)
+
+	select {
+	case b.flushReqs <- reply:
+	case <-b.done:
+		return ErrClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	select {
+	case err := <-reply:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close stops accepting new rows, flushes the buffered ones and waits until they are inserted or ctx is done.
+// If ctx is done first, the pending inserts are canceled.
+func (b *Batcher[T]) Close(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.stop)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.done:
+		return b.closeErr
+	case <-ctx.Done():
+		b.cancel()
+		return ctx.Err()
+	}
+}
+
+func (b *Batcher[T]) run() {
+	defer close(b.done)
+	defer b.cancel()
+
+	ticker := time.NewTicker(b.flushInterval)
+	defer ticker.Stop()
+
+	buf := make([]T, 0, b.batchSize)
+	for {
+		select {
+		case row := <-b.rows:
+			buf = append(buf, row)
+			if len(buf) >= b.batchSize {
+				buf, _ = b.flush(buf)
+			}
+
+		case <-ticker.C:
+			buf, _ = b.flush(buf)
+
+		case reply := <-b.flushReqs:
+			var err error
+			buf, err = b.flush(b.drain(buf))
+			reply <- err
+
+		case <-b.stop:
+			// blocked Adds return once stop is closed, the others may still have sent their row
+			b.adding.Wait()
+			_, b.closeErr = b.flush(b.drain(buf))
+			return
+		}
+	}
+}
+
+// drain moves all queued rows into buf.
+func (b *Batcher[T]) drain(buf []T) []T {
+	for {
+		select {
+		case row := <-b.rows:
+			buf = append(buf, row)
+		default:
+			return buf
+		}
+	}
+}
+
+// flush inserts buf in chunks of batchSize and returns the emptied buffer.
+func (b *Batcher[T]) flush(buf []T) ([]T, error) {
+	if len(buf) == 0 {
+		return buf, nil
+	}
+
+	var errs []error
+	for start := 0; start < len(buf); start += b.batchSize {
+		rows := buf[start:min(start+b.batchSize, len(buf))]
+		if err := b.insert(rows); err != nil {
+			slog.Error("Could not insert batch", sloki.WrapError(err), slog.Int("rows", len(rows)))
+			if b.onError != nil {
+				b.onError(rows, err)
+			}
+			errs = append(errs, err)
+		}
+	}
+
+	// the sink may keep a reference to the rows, so the buffer can't be reused
+	return make([]T, 0, b.batchSize), errors.Join(errs...)
+}
+
+func (b *Batcher[T]) insert(rows []T) error {
+	backoff := b.retryBackoff
+
+	var err error
+	for attempt := 0; attempt <= b.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(backoff):
+			case <-b.ctx.Done():
+				return fmt.Errorf("could not insert %d rows: %w", len(rows), errors.Join(err, b.ctx.Err()))
+			}
+			backoff *= 2
+		}
+
+		err = b.sink.Insert(b.ctx, rows)
+		if err == nil || !b.isRetryable(err) {
+			break
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("could not insert %d rows: %w", len(rows), err)
+	}
+
+	return nil
+}
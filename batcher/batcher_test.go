@@ -0,0 +1,227 @@
+package batcher_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/OliverSchlueter/goutils/batcher"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatcher_FlushOnSize(t *testing.T) {
+	sink := batcher.NewFakeSink[int]()
+	b := batcher.New(batcher.Configuration[int]{
+		Sink:          sink,
+		BatchSize:     3,
+		FlushInterval: time.Hour,
+	})
+	defer b.Close(context.Background())
+
+	for i := 0; i < 7; i++ {
+		require.NoError(t, b.Add(context.Background(), i))
+	}
+
+	assert.Eventually(t, func() bool {
+		return len(sink.Batches()) == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, [][]int{{0, 1, 2}, {3, 4, 5}}, sink.Batches())
+
+	require.NoError(t, b.Flush(context.Background()))
+	assert.Equal(t, []int{0, 1, 2, 3, 4, 5, 6}, sink.Rows())
+}
+
+func TestBatcher_FlushOnInterval(t *testing.T) {
+	sink := batcher.NewFakeSink[string]()
+	b := batcher.New(batcher.Configuration[string]{
+		Sink:          sink,
+		BatchSize:     100,
+		FlushInterval: 20 * time.Millisecond,
+	})
+	defer b.Close(context.Background())
+
+	require.NoError(t, b.Add(context.Background(), "a"))
+	require.NoError(t, b.Add(context.Background(), "b"))
+
+	assert.Eventually(t, func() bool {
+		return len(sink.Rows()) == 2
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestBatcher_Retry(t *testing.T) {
+	sink := batcher.NewFakeSink[int]()
+	sink.FailNext(errors.New("connection reset"), errors.New("connection reset"))
+
+	b := batcher.New(batcher.Configuration[int]{
+		Sink:         sink,
+		RetryBackoff: time.Millisecond,
+	})
+	defer b.Close(context.Background())
+
+	require.NoError(t, b.Add(context.Background(), 1))
+	require.NoError(t, b.Flush(context.Background()))
+	assert.Equal(t, []int{1}, sink.Rows())
+}
+
+func TestBatcher_RetryExhausted(t *testing.T) {
+	sink := batcher.NewFakeSink[int]()
+	sink.FailNext(errors.New("1"), errors.New("2"), errors.New("3"))
+
+	var dropped []int
+	b := batcher.New(batcher.Configuration[int]{
+		Sink:         sink,
+		MaxRetries:   2,
+		RetryBackoff: time.Millisecond,
+		OnError: func(rows []int, err error) {
+			dropped = append(dropped, rows...)
+		},
+	})
+	defer b.Close(context.Background())
+
+	require.NoError(t, b.Add(context.Background(), 1))
+	assert.ErrorContains(t, b.Flush(context.Background()), "3")
+	assert.Equal(t, []int{1}, dropped)
+	assert.Empty(t, sink.Rows())
+}
+
+func TestBatcher_NonRetryable(t *testing.T) {
+	sink := batcher.NewFakeSink[int]()
+	sink.FailNext(context.DeadlineExceeded)
+
+	b := batcher.New(batcher.Configuration[int]{
+		Sink: sink,
+	})
+	defer b.Close(context.Background())
+
+	require.NoError(t, b.Add(context.Background(), 1))
+	assert.ErrorIs(t, b.Flush(context.Background()), context.DeadlineExceeded)
+
+	// the row is dropped and the next insert succeeds
+	require.NoError(t, b.Add(context.Background(), 2))
+	require.NoError(t, b.Flush(context.Background()))
+	assert.Equal(t, []int{2}, sink.Rows())
+}
+
+type blockingSink struct {
+	release chan struct{}
+	batcher.FakeSink[int]
+}
+
+func (s *blockingSink) Insert(ctx context.Context, rows []int) error {
+	select {
+	case <-s.release:
+		return s.FakeSink.Insert(ctx, rows)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestBatcher_BackPressure(t *testing.T) {
+	sink := &blockingSink{release: make(chan struct{})}
+	b := batcher.New(batcher.Configuration[int]{
+		Sink:          sink,
+		BatchSize:     1,
+		BufferSize:    2,
+		FlushInterval: time.Hour,
+	})
+
+	// the first row is taken by the blocked insert, the next two fill the buffer
+	for i := 0; i < 3; i++ {
+		require.NoError(t, b.Add(context.Background(), i))
+	}
+	require.Eventually(t, func() bool {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		return errors.Is(b.Add(ctx, 99), context.DeadlineExceeded)
+	}, time.Second, time.Millisecond)
+
+	close(sink.release)
+	require.NoError(t, b.Close(context.Background()))
+	assert.Equal(t, []int{0, 1, 2}, sink.Rows()[:3])
+}
+
+func TestBatcher_Close(t *testing.T) {
+	sink := batcher.NewFakeSink[int]()
+	b := batcher.New(batcher.Configuration[int]{
+		Sink:          sink,
+		FlushInterval: time.Hour,
+	})
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, b.Add(context.Background(), i))
+		}(i)
+	}
+	wg.Wait()
+
+	require.NoError(t, b.Close(context.Background()))
+	assert.Len(t, sink.Rows(), 10)
+
+	assert.ErrorIs(t, b.Add(context.Background(), 11), batcher.ErrClosed)
+	assert.ErrorIs(t, b.Flush(context.Background()), batcher.ErrClosed)
+	assert.NoError(t, b.Close(context.Background()))
+}
+
+func TestBatcher_CloseWithBlockedAdd(t *testing.T) {
+	sink := &blockingSink{release: make(chan struct{})}
+	b := batcher.New(batcher.Configuration[int]{
+		Sink:          sink,
+		BatchSize:     1,
+		BufferSize:    1,
+		FlushInterval: time.Hour,
+	})
+
+	require.NoError(t, b.Add(context.Background(), 0))
+	require.NoError(t, b.Add(context.Background(), 1))
+
+	added := make(chan error, 1)
+	go func() {
+		added <- b.Add(context.Background(), 2)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// the sink never returns, so Close has to give up instead of waiting for the blocked Add
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, b.Close(ctx), context.DeadlineExceeded)
+
+	select {
+	case err := <-added:
+		assert.ErrorIs(t, err, batcher.ErrClosed)
+	case <-time.After(time.Second):
+		t.Fatal("Add is still blocked after Close")
+	}
+}
+
+func TestBatcher_CloseCancelsRetries(t *testing.T) {
+	sink := batcher.NewFakeSink[int]()
+	sink.FailNext(errors.New("connection reset"))
+
+	dropped := make(chan error, 1)
+	b := batcher.New(batcher.Configuration[int]{
+		Sink:          sink,
+		FlushInterval: time.Hour,
+		RetryBackoff:  time.Hour,
+		OnError: func(rows []int, err error) {
+			dropped <- err
+		},
+	})
+	require.NoError(t, b.Add(context.Background(), 1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, b.Close(ctx), context.DeadlineExceeded)
+
+	select {
+	case err := <-dropped:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("retry backoff was not canceled")
+	}
+}
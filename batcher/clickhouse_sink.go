@@ -0,0 +1,52 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+)
+
+// ClickhouseSink inserts rows into a ClickHouse table.
+// T must be a struct whose fields are mapped to columns with `ch:"column"` tags.
+type ClickhouseSink[T any] struct {
+	conn  driver.Conn
+	table string
+}
+
+func NewClickhouseSink[T any](conn driver.Conn, table string) *ClickhouseSink[T] {
+	return &ClickhouseSink[T]{
+		conn:  conn,
+		table: table,
+	}
+}
+
+func (s *ClickhouseSink[T]) Insert(ctx context.Context, rows []T) error {
+	batch, err := s.conn.PrepareBatch(ctx, "INSERT INTO "+s.table)
+	if err != nil {
+		return fmt.Errorf("could not prepare batch: %w", err)
+	}
+	defer batch.Close()
+
+	for i := range rows {
+		if err := batch.AppendStruct(&rows[i]); err != nil {
+			_ = batch.Abort()
+			return fmt.Errorf("could not append row: %w", err)
+		}
+	}
+
+	return batch.Send()
+}
+
+// IsClickhouseRetryable reports whether err is a transient error.
+// Exceptions returned by the server (e.g. syntax or type errors) won't succeed on retry.
+func IsClickhouseRetryable(err error) bool {
+	var exception *clickhouse.Exception
+	if errors.As(err, &exception) {
+		return false
+	}
+
+	return IsRetryable(err)
+}
@@ -0,0 +1,59 @@
+package batcher
+
+import (
+	"context"
+	"sync"
+)
+
+// FakeSink is an in-memory Sink for tests.
+type FakeSink[T any] struct {
+	mu      sync.Mutex
+	batches [][]T
+	errs    []error
+}
+
+func NewFakeSink[T any]() *FakeSink[T] {
+	return &FakeSink[T]{}
+}
+
+// FailNext makes the next len(errs) inserts fail with the given errors, in order.
+func (s *FakeSink[T]) FailNext(errs ...error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.errs = append(s.errs, errs...)
+}
+
+func (s *FakeSink[T]) Insert(_ context.Context, rows []T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.errs) > 0 {
+		err := s.errs[0]
+		s.errs = s.errs[1:]
+		return err
+	}
+
+	s.batches = append(s.batches, append([]T(nil), rows...))
+	return nil
+}
+
+// Batches returns all successfully inserted batches.
+func (s *FakeSink[T]) Batches() [][]T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([][]T(nil), s.batches...)
+}
+
+// Rows returns all successfully inserted rows.
+func (s *FakeSink[T]) Rows() []T {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var rows []T
+	for _, b := range s.batches {
+		rows = append(rows, b...)
+	}
+	return rows
+}
@@ -1,6 +1,12 @@
 package broker
 
-import "github.com/nats-io/nats.go"
+import (
+	"errors"
+
+	"github.com/nats-io/nats.go"
+)
+
+var ErrHeadersNotSupported = errors.New("broker does not support message headers")
 
 type Broker interface {
 	Publish(subject string, data []byte) error
@@ -8,3 +14,21 @@ type Broker interface {
 	Subscribe(subject string, handler nats.MsgHandler) error
 	SubscribeQueue(subject, queue string, handler nats.MsgHandler) error
 }
+
+// MsgPublisher is implemented by brokers that can publish messages with headers.
+type MsgPublisher interface {
+	PublishMsg(msg *nats.Msg) error
+}
+
+// PublishMsg publishes msg with b. If b does not implement MsgPublisher, only the data of msg is published,
+// and ErrHeadersNotSupported is returned if msg has headers, as they would be lost.
+func PublishMsg(b Broker, msg *nats.Msg) error {
+	if p, ok := b.(MsgPublisher); ok {
+		return p.PublishMsg(msg)
+	}
+	if len(msg.Header) > 0 {
+		return ErrHeadersNotSupported
+	}
+
+	return b.Publish(msg.Subject, msg.Data)
+}
@@ -1,15 +1,23 @@
 package broker_test
 
 import (
+	"testing"
+
 	"github.com/OliverSchlueter/goutils/broker"
 	"github.com/OliverSchlueter/goutils/broker/brokertest"
-	"testing"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestFakeBroker_Publish(t *testing.T) {
 	brokertest.TestPublish(t, broker.NewFakeBroker())
 }
 
+func TestFakeBroker_PublishMsg(t *testing.T) {
+	brokertest.TestPublishMsg(t, broker.NewFakeBroker())
+}
+
 func TestFakeBroker_Request(t *testing.T) {
 	brokertest.TestRequest(t, broker.NewFakeBroker())
 }
@@ -21,3 +29,53 @@ func TestFakeBroker_Subscribe(t *testing.T) {
 func TestFakeBroker_SubscribeQueue(t *testing.T) {
 	brokertest.TestSubscribeQueue(t, broker.NewFakeBroker())
 }
+
+func TestFakeBroker_PublishMsgCopies(t *testing.T) {
+	fb := broker.NewFakeBroker()
+
+	var received []*nats.Msg
+	for range 2 {
+		require.NoError(t, fb.Subscribe("test", func(msg *nats.Msg) {
+			received = append(received, msg)
+			if len(received) == 1 {
+				msg.Header.Set("X-Test", "changed")
+				msg.Data[0] = 'D'
+			}
+		}))
+	}
+
+	msg := &nats.Msg{Subject: "test", Header: nats.Header{"X-Test": {"original"}}, Data: []byte("data")}
+	require.NoError(t, fb.PublishMsg(msg))
+
+	require.Len(t, received, 2)
+	assert.NotSame(t, msg, received[0])
+	assert.NotSame(t, received[0], received[1])
+	assert.Equal(t, "data", string(msg.Data), "The published message should not be changed")
+	assert.Equal(t, "original", msg.Header.Get("X-Test"))
+	assert.Equal(t, "data", string(received[1].Data), "Subscribers should not see changes of each other")
+	assert.Equal(t, "original", received[1].Header.Get("X-Test"))
+}
+
+// publishOnly is a broker that can't publish headers.
+type publishOnly struct {
+	*broker.FakeBroker
+}
+
+// PublishMsg shadows the method of FakeBroker with another signature, so publishOnly is no MsgPublisher.
+func (publishOnly) PublishMsg() {}
+
+func TestPublishMsg_WithoutMsgPublisher(t *testing.T) {
+	b := publishOnly{broker.NewFakeBroker()}
+
+	var received []byte
+	require.NoError(t, b.Subscribe("test", func(msg *nats.Msg) {
+		received = msg.Data
+	}))
+
+	require.NoError(t, broker.PublishMsg(b, &nats.Msg{Subject: "test", Data: []byte("data")}))
+	assert.Equal(t, "data", string(received))
+
+	msg := nats.NewMsg("test")
+	msg.Header.Set("X-Test", "test header")
+	assert.ErrorIs(t, broker.PublishMsg(b, msg), broker.ErrHeadersNotSupported)
+}
@@ -34,6 +34,38 @@ func TestPublish(t *testing.T, b broker.Broker) {
 	}
 }
 
+func TestPublishMsg(t *testing.T, b broker.Broker) {
+	if _, ok := b.(broker.MsgPublisher); !ok {
+		t.Skip("broker does not implement MsgPublisher")
+	}
+
+	subject := "test.publishmsg"
+	testData := []byte("test publish msg data")
+	receivedCh := make(chan *nats.Msg, 1)
+
+	// Set up a subscriber to verify the publish worked
+	err := b.Subscribe(subject, func(msg *nats.Msg) {
+		receivedCh <- msg
+	})
+	require.NoError(t, err, "Failed to subscribe")
+
+	// Publish the message with headers
+	msg := nats.NewMsg(subject)
+	msg.Header.Set("X-Test", "test header")
+	msg.Data = testData
+	err = broker.PublishMsg(b, msg)
+	require.NoError(t, err, "Failed to publish message")
+
+	// Wait for the message with timeout
+	select {
+	case received := <-receivedCh:
+		assert.Equal(t, testData, received.Data, "Received data doesn't match sent data")
+		assert.Equal(t, "test header", received.Header.Get("X-Test"), "Received header doesn't match sent header")
+	case <-time.After(500 * time.Millisecond):
+		assert.Fail(t, "Timed out waiting for published message")
+	}
+}
+
 func TestRequest(t *testing.T, b broker.Broker) {
 	subject := "test.request"
 	requestData := []byte("test request data")
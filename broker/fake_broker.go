@@ -1,7 +1,10 @@
 package broker
 
 import (
+	"bytes"
 	"fmt"
+	"slices"
+
 	"github.com/nats-io/nats.go"
 )
 
@@ -31,6 +34,25 @@ func (b *FakeBroker) Publish(subject string, data []byte) error {
 	return nil
 }
 
+func (b *FakeBroker) PublishMsg(msg *nats.Msg) error {
+	// subscribers get their own copy, like with a real broker
+	for _, s := range b.subscribers {
+		received := &nats.Msg{
+			Subject: msg.Subject,
+			Reply:   msg.Reply,
+			Header:  nats.Header{},
+			Data:    bytes.Clone(msg.Data),
+		}
+		for key, values := range msg.Header {
+			received.Header[key] = slices.Clone(values)
+		}
+
+		s(received)
+	}
+
+	return nil
+}
+
 func (b *FakeBroker) Request(subject string, data []byte) (*nats.Msg, error) {
 	msg := &nats.Msg{
 		Subject: subject,
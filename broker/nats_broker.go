@@ -20,6 +20,10 @@ func (b *NatsBroker) Publish(subject string, data []byte) error {
 	return b.nats.Publish(subject, data)
 }
 
+func (b *NatsBroker) PublishMsg(msg *nats.Msg) error {
+	return b.nats.PublishMsg(msg)
+}
+
 func (b *NatsBroker) Request(subject string, data []byte) (*nats.Msg, error) {
 	return b.nats.Request(subject, data, nats.DefaultTimeout)
 }
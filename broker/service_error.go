@@ -0,0 +1,93 @@
+package broker
+
+import (
+	"fmt"
+	"mime"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/nats-io/nats.go"
+)
+
+// The headers marking a reply as failed, as used by NATS services (see github.com/nats-io/nats.go/micro).
+const (
+	ServiceErrorHeader     = "Nats-Service-Error"
+	ServiceErrorCodeHeader = "Nats-Service-Error-Code"
+)
+
+// ServiceError is a reply that is marked as failed by its Nats-Service-Error-Code header.
+type ServiceError struct {
+	Subject     string
+	Code        int
+	Description string
+	// Msg is the failed reply.
+	Msg *nats.Msg
+}
+
+func (e *ServiceError) Error() string {
+	return fmt.Sprintf("request to %s failed with code %d: %s", e.Subject, e.Code, e.Description)
+}
+
+// ErrorDecoder decodes the error of a failed reply msg to a request to subject. It returns nil if the data can't be decoded.
+type ErrorDecoder func(subject string, msg *nats.Msg) error
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[string]ErrorDecoder{}
+)
+
+// RegisterErrorDecoder makes ReplyError decode failed replies with the given Content-Type header with decode.
+// Packages that can't be imported by this package register their error formats with it, e.g. problems.
+func RegisterErrorDecoder(contentType string, decode ErrorDecoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+
+	decoders[mediaType(contentType)] = decode
+}
+
+// Request sends a request with b. Failed replies are returned together with their error, see ReplyError.
+func Request(b Broker, subject string, data []byte) (*nats.Msg, error) {
+	msg, err := b.Request(subject, data)
+	if err != nil {
+		return nil, err
+	}
+
+	return msg, ReplyError(subject, msg)
+}
+
+// ReplyError returns the error of the reply msg to a request to subject, or nil if the reply is not marked as failed.
+// The error is decoded by the decoder registered for the Content-Type of msg, see RegisterErrorDecoder.
+// If there is none or it fails, a *ServiceError is returned.
+func ReplyError(subject string, msg *nats.Msg) error {
+	code := msg.Header.Get(ServiceErrorCodeHeader)
+	if code == "" {
+		return nil
+	}
+
+	decodersMu.RLock()
+	decode, ok := decoders[mediaType(msg.Header.Get("Content-Type"))]
+	decodersMu.RUnlock()
+
+	if ok {
+		if err := decode(subject, msg); err != nil {
+			return err
+		}
+	}
+
+	status, _ := strconv.Atoi(code)
+	return &ServiceError{
+		Subject:     subject,
+		Code:        status,
+		Description: msg.Header.Get(ServiceErrorHeader),
+		Msg:         msg,
+	}
+}
+
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+	return mt
+}
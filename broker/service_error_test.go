@@ -0,0 +1,72 @@
+package broker_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/OliverSchlueter/goutils/broker"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequest_ServiceError(t *testing.T) {
+	fb := broker.NewFakeBroker()
+	fb.SetRequestHandler(func(msg *nats.Msg) (*nats.Msg, error) {
+		reply := nats.NewMsg(msg.Reply)
+		reply.Header.Set(broker.ServiceErrorHeader, "Not found")
+		reply.Header.Set(broker.ServiceErrorCodeHeader, "404")
+		return reply, nil
+	})
+
+	msg, err := broker.Request(fb, "users.get", []byte("12345"))
+	require.Error(t, err)
+	require.NotNil(t, msg)
+
+	var serviceErr *broker.ServiceError
+	require.True(t, errors.As(err, &serviceErr))
+	assert.Equal(t, "users.get", serviceErr.Subject)
+	assert.Equal(t, 404, serviceErr.Code)
+	assert.Equal(t, "Not found", serviceErr.Description)
+	assert.Same(t, msg, serviceErr.Msg)
+}
+
+func TestRequest_ErrorDecoder(t *testing.T) {
+	errDecoded := errors.New("decoded")
+	broker.RegisterErrorDecoder("application/vnd.test+json", func(subject string, msg *nats.Msg) error {
+		if string(msg.Data) == "invalid" {
+			return nil
+		}
+		return errDecoded
+	})
+
+	fb := broker.NewFakeBroker()
+	data := "valid"
+	fb.SetRequestHandler(func(msg *nats.Msg) (*nats.Msg, error) {
+		reply := nats.NewMsg(msg.Reply)
+		reply.Header.Set("Content-Type", "application/vnd.test+json; charset=utf-8")
+		reply.Header.Set(broker.ServiceErrorCodeHeader, "500")
+		reply.Data = []byte(data)
+		return reply, nil
+	})
+
+	_, err := broker.Request(fb, "test", nil)
+	assert.ErrorIs(t, err, errDecoded)
+
+	// replies the decoder can't decode are returned as ServiceError
+	data = "invalid"
+	_, err = broker.Request(fb, "test", nil)
+	var serviceErr *broker.ServiceError
+	assert.True(t, errors.As(err, &serviceErr))
+}
+
+func TestRequest_Success(t *testing.T) {
+	fb := broker.NewFakeBroker()
+	fb.SetRequestHandler(func(msg *nats.Msg) (*nats.Msg, error) {
+		return &nats.Msg{Subject: msg.Reply, Data: []byte("ok")}, nil
+	})
+
+	msg, err := broker.Request(fb, "test", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("ok"), msg.Data)
+}
@@ -0,0 +1,309 @@
+// Package cache implements the cache-aside pattern on top of Redis with an optional local in-memory tier.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/OliverSchlueter/goutils/broker"
+	"github.com/OliverSchlueter/goutils/sloki"
+	"github.com/dgraph-io/ristretto/v2"
+	"github.com/nats-io/nats.go"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	// ErrNotFound is returned by loaders if the value does not exist. It is cached for Configuration.NegativeTTL.
+	ErrNotFound = errors.New("not found")
+	// ErrMiss is returned by Get if the key is not cached.
+	ErrMiss = errors.New("cache miss")
+)
+
+// notFoundMarker is stored in Redis for negatively cached keys. It can't clash with JSON values, which are never empty.
+const notFoundMarker = ""
+
+type entry[T any] struct {
+	value    T
+	notFound bool
+}
+
+type Cache[T any] struct {
+	redis       redis.Cmdable
+	name        string
+	ttl         time.Duration
+	jitter      time.Duration
+	negativeTTL time.Duration
+	local       *ristretto.Cache[string, entry[T]]
+	localTTL    time.Duration
+	broker      broker.Broker
+	loadTimeout time.Duration
+	group       singleflight.Group
+	closed      atomic.Bool
+}
+
+type Configuration struct {
+	Redis redis.Cmdable
+	// Name is used as prefix of the Redis keys and for the invalidation subject.
+	Name string
+	// TTL is the expiry of cached values. Defaults to 5 minutes.
+	TTL time.Duration
+	// Jitter is the maximum random duration added to TTL, so keys cached at the same time don't expire at once.
+	Jitter time.Duration
+	// NegativeTTL is the expiry of cached ErrNotFound results. Zero disables negative caching.
+	NegativeTTL time.Duration
+	// LocalMaxItems is the number of items kept in the local in-memory tier. Zero disables the local tier.
+	LocalMaxItems int64
+	// LocalTTL is the expiry of items in the local tier. Defaults to TTL.
+	LocalTTL time.Duration
+	// Broker is used to broadcast invalidations to the local tiers of other replicas. Optional.
+	Broker broker.Broker
+	// LoadTimeout limits the duration of a shared load, which is not canceled by the callers waiting for it. Defaults to 30 seconds.
+	LoadTimeout time.Duration
+}
+
+func New[T any](cfg Configuration) (*Cache[T], error) {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 5 * time.Minute
+	}
+	if cfg.LocalTTL <= 0 {
+		cfg.LocalTTL = cfg.TTL
+	}
+	if cfg.LoadTimeout <= 0 {
+		cfg.LoadTimeout = 30 * time.Second
+	}
+
+	c := &Cache[T]{
+		redis:       cfg.Redis,
+		name:        cfg.Name,
+		ttl:         cfg.TTL,
+		jitter:      cfg.Jitter,
+		negativeTTL: cfg.NegativeTTL,
+		localTTL:    cfg.LocalTTL,
+		broker:      cfg.Broker,
+		loadTimeout: cfg.LoadTimeout,
+	}
+
+	if cfg.LocalMaxItems > 0 {
+		local, err := ristretto.NewCache(&ristretto.Config[string, entry[T]]{
+			NumCounters: cfg.LocalMaxItems * 10, // x10 of expected number of elements when full
+			MaxCost:     cfg.LocalMaxItems,
+			BufferItems: 64,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not create local cache: %w", err)
+		}
+		c.local = local
+	}
+
+	if c.broker != nil && c.local != nil {
+		if err := c.broker.Subscribe(c.invalidationSubject(), c.handleInvalidation); err != nil {
+			return nil, fmt.Errorf("could not subscribe to invalidations: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+// Get returns the cached value of key.
+// It returns ErrMiss if the key is not cached and ErrNotFound if it is negatively cached.
+func (c *Cache[T]) Get(ctx context.Context, key string) (T, error) {
+	var zero T
+
+	if e, ok := c.getLocal(key); ok {
+		if e.notFound {
+			return zero, ErrNotFound
+		}
+		return e.value, nil
+	}
+
+	data, err := c.redis.Get(ctx, c.redisKey(key)).Result()
+	if errors.Is(err, redis.Nil) {
+		return zero, ErrMiss
+	}
+	if err != nil {
+		return zero, fmt.Errorf("could not get %s from redis: %w", key, err)
+	}
+
+	if data == notFoundMarker {
+		c.setLocal(key, entry[T]{notFound: true})
+		return zero, ErrNotFound
+	}
+
+	var value T
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		return zero, fmt.Errorf("could not unmarshal %s: %w", key, err)
+	}
+
+	c.setLocal(key, entry[T]{value: value})
+	return value, nil
+}
+
+// GetOrLoad returns the cached value of key, or calls load and caches its result.
+// Concurrent calls for the same key share a single call of load.
+// If load returns ErrNotFound, it is cached for Configuration.NegativeTTL. Other errors are not cached.
+func (c *Cache[T]) GetOrLoad(ctx context.Context, key string, load func(ctx context.Context) (T, error)) (T, error) {
+	value, err := c.Get(ctx, key)
+	if err == nil || errors.Is(err, ErrNotFound) {
+		return value, err
+	}
+	if !errors.Is(err, ErrMiss) {
+		// Redis is unavailable, fall back to the source
+		slog.Warn("Could not read from cache", sloki.WrapError(err), slog.String("key", key))
+	}
+
+	// the load is shared, so it must not be canceled by the caller that happens to start it
+	ch := c.group.DoChan(key, func() (any, error) {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.loadTimeout)
+		defer cancel()
+
+		value, err := load(ctx)
+		if errors.Is(err, ErrNotFound) {
+			c.setNotFound(ctx, key)
+			return value, err
+		}
+		if err != nil {
+			return value, err
+		}
+
+		if err := c.Set(ctx, key, value); err != nil {
+			slog.Warn("Could not write to cache", sloki.WrapError(err), slog.String("key", key))
+		}
+		return value, nil
+	})
+
+	select {
+	case res := <-ch:
+		value, _ = res.Val.(T)
+		return value, res.Err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Set caches value for key and removes the previous value from the local tiers of all replicas.
+func (c *Cache[T]) Set(ctx context.Context, key string, value T) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("could not marshal %s: %w", key, err)
+	}
+
+	if err := c.redis.Set(ctx, c.redisKey(key), data, c.withJitter(c.ttl)).Err(); err != nil {
+		return fmt.Errorf("could not set %s in redis: %w", key, err)
+	}
+
+	if err := c.broadcastInvalidation(key); err != nil {
+		return err
+	}
+
+	c.setLocal(key, entry[T]{value: value})
+	return nil
+}
+
+// Invalidate removes key from the cache and from the local tiers of all replicas.
+func (c *Cache[T]) Invalidate(ctx context.Context, key string) error {
+	if c.local != nil {
+		c.local.Del(key)
+	}
+
+	if err := c.redis.Del(ctx, c.redisKey(key)).Err(); err != nil {
+		return fmt.Errorf("could not delete %s from redis: %w", key, err)
+	}
+
+	return c.broadcastInvalidation(key)
+}
+
+// Close stops handling invalidations and releases the local tier.
+// The cache must not be used afterwards.
+func (c *Cache[T]) Close() {
+	if c.closed.Swap(true) {
+		return
+	}
+
+	if c.local != nil {
+		c.local.Close()
+	}
+}
+
+func (c *Cache[T]) setNotFound(ctx context.Context, key string) {
+	if c.negativeTTL <= 0 {
+		return
+	}
+
+	if err := c.redis.Set(ctx, c.redisKey(key), notFoundMarker, c.withJitter(c.negativeTTL)).Err(); err != nil {
+		slog.Warn("Could not write to cache", sloki.WrapError(err), slog.String("key", key))
+		return
+	}
+
+	if err := c.broadcastInvalidation(key); err != nil {
+		slog.Warn("Could not broadcast invalidation", sloki.WrapError(err), slog.String("key", key))
+	}
+
+	c.setLocal(key, entry[T]{notFound: true})
+}
+
+func (c *Cache[T]) getLocal(key string) (entry[T], bool) {
+	if c.local == nil {
+		return entry[T]{}, false
+	}
+
+	return c.local.Get(key)
+}
+
+func (c *Cache[T]) setLocal(key string, e entry[T]) {
+	if c.local == nil {
+		return
+	}
+
+	ttl := c.localTTL
+	if e.notFound && c.negativeTTL > 0 {
+		ttl = min(ttl, c.negativeTTL)
+	}
+	c.local.SetWithTTL(key, e, 1, ttl)
+}
+
+// broadcastInvalidation tells the other replicas to drop key from their local tiers.
+// The own local tier receives it too, which only causes a read from Redis.
+func (c *Cache[T]) broadcastInvalidation(key string) error {
+	if c.broker == nil {
+		return nil
+	}
+
+	if err := c.broker.Publish(c.invalidationSubject(), []byte(key)); err != nil {
+		return fmt.Errorf("could not broadcast invalidation of %s: %w", key, err)
+	}
+
+	return nil
+}
+
+func (c *Cache[T]) handleInvalidation(msg *nats.Msg) {
+	// the broker can't unsubscribe, so invalidations are ignored once the cache is closed
+	if c.closed.Load() || msg.Subject != c.invalidationSubject() {
+		return
+	}
+
+	c.local.Del(string(msg.Data))
+}
+
+func (c *Cache[T]) withJitter(ttl time.Duration) time.Duration {
+	if c.jitter <= 0 {
+		return ttl
+	}
+
+	return ttl + rand.N(c.jitter)
+}
+
+func (c *Cache[T]) redisKey(key string) string {
+	return c.name + ":" + key
+}
+
+func (c *Cache[T]) invalidationSubject() string {
+	return "cache.invalidate." + c.name
+}
@@ -0,0 +1,280 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OliverSchlueter/goutils/broker"
+	"github.com/OliverSchlueter/goutils/cache"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type user struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func newRedis(t *testing.T) (*redis.Client, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rc.Close() })
+
+	return rc, mr
+}
+
+func TestCache_GetOrLoad(t *testing.T) {
+	rc, mr := newRedis(t)
+	c, err := cache.New[user](cache.Configuration{
+		Redis:  rc,
+		Name:   "users",
+		TTL:    time.Minute,
+		Jitter: 10 * time.Second,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	loads := 0
+	load := func(ctx context.Context) (user, error) {
+		loads++
+		return user{ID: "1", Name: "Alice"}, nil
+	}
+
+	_, err = c.Get(ctx, "1")
+	assert.ErrorIs(t, err, cache.ErrMiss)
+
+	u, err := c.GetOrLoad(ctx, "1", load)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", u.Name)
+
+	u, err = c.GetOrLoad(ctx, "1", load)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", u.Name)
+	assert.Equal(t, 1, loads)
+
+	data, err := mr.Get("users:1")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"id":"1","name":"Alice"}`, data)
+
+	ttl := mr.TTL("users:1")
+	assert.GreaterOrEqual(t, ttl, time.Minute)
+	assert.Less(t, ttl, time.Minute+10*time.Second)
+}
+
+func TestCache_LoadError(t *testing.T) {
+	rc, mr := newRedis(t)
+	c, err := cache.New[user](cache.Configuration{Redis: rc, Name: "users"})
+	require.NoError(t, err)
+
+	loadErr := errors.New("database down")
+	_, err = c.GetOrLoad(context.Background(), "1", func(ctx context.Context) (user, error) {
+		return user{}, loadErr
+	})
+	assert.ErrorIs(t, err, loadErr)
+	assert.False(t, mr.Exists("users:1"))
+}
+
+func TestCache_NegativeCaching(t *testing.T) {
+	rc, mr := newRedis(t)
+	c, err := cache.New[user](cache.Configuration{
+		Redis:       rc,
+		Name:        "users",
+		NegativeTTL: 10 * time.Second,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	loads := 0
+	load := func(ctx context.Context) (user, error) {
+		loads++
+		return user{}, cache.ErrNotFound
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err = c.GetOrLoad(ctx, "missing", load)
+		assert.ErrorIs(t, err, cache.ErrNotFound)
+	}
+	assert.Equal(t, 1, loads)
+
+	mr.FastForward(11 * time.Second)
+	_, err = c.GetOrLoad(ctx, "missing", load)
+	assert.ErrorIs(t, err, cache.ErrNotFound)
+	assert.Equal(t, 2, loads)
+}
+
+func TestCache_Singleflight(t *testing.T) {
+	rc, _ := newRedis(t)
+	c, err := cache.New[int](cache.Configuration{Redis: rc, Name: "numbers"})
+	require.NoError(t, err)
+
+	var loads atomic.Int32
+	release := make(chan struct{})
+	load := func(ctx context.Context) (int, error) {
+		loads.Add(1)
+		<-release
+		return 42, nil
+	}
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.GetOrLoad(context.Background(), "answer", load)
+			assert.NoError(t, err)
+			assert.Equal(t, 42, v)
+		}()
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), loads.Load())
+}
+
+func TestCache_SingleflightCanceledCaller(t *testing.T) {
+	rc, _ := newRedis(t)
+	c, err := cache.New[int](cache.Configuration{Redis: rc, Name: "numbers"})
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	load := func(ctx context.Context) (int, error) {
+		close(started)
+		<-release
+		return 42, ctx.Err()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := c.GetOrLoad(ctx, "answer", load)
+		first <- err
+	}()
+	<-started
+
+	second := make(chan int, 1)
+	go func() {
+		v, err := c.GetOrLoad(context.Background(), "answer", load)
+		assert.NoError(t, err)
+		second <- v
+	}()
+
+	// the first caller gives up, but the shared load must go on for the second one
+	cancel()
+	assert.ErrorIs(t, <-first, context.Canceled)
+
+	close(release)
+	assert.Equal(t, 42, <-second)
+}
+
+func TestCache_LocalTier(t *testing.T) {
+	rc, mr := newRedis(t)
+	c, err := cache.New[string](cache.Configuration{
+		Redis:         rc,
+		Name:          "strings",
+		LocalMaxItems: 100,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, c.Set(ctx, "key", "value"))
+
+	// served from the local tier, even if Redis is unavailable
+	mr.Close()
+	assert.Eventually(t, func() bool {
+		v, err := c.Get(ctx, "key")
+		return err == nil && v == "value"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCache_InvalidationBroadcast(t *testing.T) {
+	rc, mr := newRedis(t)
+	b := broker.NewFakeBroker()
+
+	newCache := func() *cache.Cache[string] {
+		c, err := cache.New[string](cache.Configuration{
+			Redis:         rc,
+			Name:          "strings",
+			LocalMaxItems: 100,
+			Broker:        b,
+		})
+		require.NoError(t, err)
+		return c
+	}
+	c1, c2 := newCache(), newCache()
+
+	ctx := context.Background()
+	require.NoError(t, c1.Set(ctx, "key", "old"))
+	require.Eventually(t, func() bool {
+		v, err := c2.Get(ctx, "key")
+		return err == nil && v == "old"
+	}, time.Second, 10*time.Millisecond)
+
+	// c2 now has the value in its local tier, so it would keep serving it without the broadcast
+	require.NoError(t, c1.Invalidate(ctx, "key"))
+	require.NoError(t, mr.Set("strings:key", `"new"`))
+
+	v, err := c2.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "new", v)
+}
+
+func TestCache_SetBroadcast(t *testing.T) {
+	rc, _ := newRedis(t)
+	b := broker.NewFakeBroker()
+
+	newCache := func() *cache.Cache[string] {
+		c, err := cache.New[string](cache.Configuration{
+			Redis:         rc,
+			Name:          "strings",
+			LocalMaxItems: 100,
+			Broker:        b,
+		})
+		require.NoError(t, err)
+		t.Cleanup(c.Close)
+		return c
+	}
+	c1, c2 := newCache(), newCache()
+
+	ctx := context.Background()
+	require.NoError(t, c1.Set(ctx, "key", "old"))
+	require.Eventually(t, func() bool {
+		v, err := c2.Get(ctx, "key")
+		return err == nil && v == "old"
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, c1.Set(ctx, "key", "new"))
+
+	v, err := c2.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, "new", v)
+}
+
+func TestCache_Close(t *testing.T) {
+	rc, _ := newRedis(t)
+	b := broker.NewFakeBroker()
+
+	c, err := cache.New[string](cache.Configuration{
+		Redis:         rc,
+		Name:          "strings",
+		LocalMaxItems: 100,
+		Broker:        b,
+	})
+	require.NoError(t, err)
+
+	c.Close()
+	c.Close()
+
+	// invalidations arriving after Close are ignored
+	assert.NotPanics(t, func() {
+		require.NoError(t, b.Publish("cache.invalidate.strings", []byte("key")))
+	})
+}
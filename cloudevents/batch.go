@@ -0,0 +1,241 @@
+package cloudevents
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/OliverSchlueter/goutils/broker"
+	"github.com/OliverSchlueter/goutils/problems"
+)
+
+// EventError is the error of a single event in a batch.
+type EventError struct {
+	Index int
+	ID    string
+	Err   error
+}
+
+func (e *EventError) Error() string {
+	if e.ID == "" {
+		return fmt.Sprintf("event #%d: %s", e.Index, e.Err)
+	}
+
+	return fmt.Sprintf("event #%d (id '%s'): %s", e.Index, e.ID, e.Err)
+}
+
+func (e *EventError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError lists all events of a batch that could not be decoded or are invalid.
+type BatchError struct {
+	Total  int
+	Errors []*EventError
+}
+
+func (e *BatchError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+
+	return fmt.Sprintf("%d of %d events are invalid: %s", len(e.Errors), e.Total, strings.Join(msgs, "; "))
+}
+
+// Problem returns an InvalidEventProblem listing the errors of all invalid events.
+func (e *BatchError) Problem() *problems.Problem {
+	violations := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		violations[i] = err.Error()
+	}
+
+	return InvalidEventProblem(violations)
+}
+
+// EncodeBatch encodes events in the JSON batch format.
+func EncodeBatch(events []*CloudEvent) ([]byte, error) {
+	if events == nil {
+		events = []*CloudEvent{}
+	}
+
+	data, err := json.Marshal(events)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode batch: %w", err)
+	}
+
+	return data, nil
+}
+
+// DecodeBatch decodes and validates a batch in the JSON batch format.
+// If some events are invalid, the valid events are returned together with a *BatchError.
+func DecodeBatch(data []byte) ([]*CloudEvent, error) {
+	return decodeAll(NewBatchDecoder(bytes.NewReader(data)))
+}
+
+// ValidateBatch validates all events and returns a *BatchError listing the invalid ones, or nil.
+func ValidateBatch(events []*CloudEvent) *BatchError {
+	batchErr := &BatchError{Total: len(events)}
+	for i, e := range events {
+		if e == nil {
+			batchErr.Errors = append(batchErr.Errors, &EventError{Index: i, Err: errors.New("event is null")})
+			continue
+		}
+		if err := validationError(e); err != nil {
+			batchErr.Errors = append(batchErr.Errors, &EventError{Index: i, ID: e.ID, Err: err})
+		}
+	}
+
+	if len(batchErr.Errors) == 0 {
+		return nil
+	}
+	return batchErr
+}
+
+// BatchDecoder decodes the events of a batch one by one, so large batches don't have to be held in memory.
+type BatchDecoder struct {
+	dec     *json.Decoder
+	index   int
+	started bool
+	done    bool
+}
+
+func NewBatchDecoder(r io.Reader) *BatchDecoder {
+	return &BatchDecoder{
+		dec: json.NewDecoder(r),
+	}
+}
+
+// Next decodes and validates the next event. It returns io.EOF after the last event.
+// If a single event is malformed or invalid, an *EventError is returned and decoding can continue
+// with the next call. All other errors are fatal.
+func (d *BatchDecoder) Next() (*CloudEvent, error) {
+	if d.done {
+		return nil, io.EOF
+	}
+
+	if !d.started {
+		d.started = true
+		tok, err := d.dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("could not decode batch: %w", unexpectedEOF(err))
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return nil, fmt.Errorf("could not decode batch: expected array, got %v", tok)
+		}
+	}
+
+	if !d.dec.More() {
+		d.done = true
+		if _, err := d.dec.Token(); err != nil {
+			return nil, fmt.Errorf("could not decode batch: %w", unexpectedEOF(err))
+		}
+		return nil, io.EOF
+	}
+
+	index := d.index
+	d.index++
+
+	var e CloudEvent
+	if err := d.dec.Decode(&e); err != nil {
+		var (
+			syntaxErr   *json.SyntaxError
+			maxBytesErr *http.MaxBytesError
+		)
+		if errors.As(err, &syntaxErr) || errors.As(err, &maxBytesErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("could not decode batch: %w", err)
+		}
+		return nil, &EventError{Index: index, Err: err}
+	}
+
+	if err := validationError(&e); err != nil {
+		return nil, &EventError{Index: index, ID: e.ID, Err: err}
+	}
+
+	return &e, nil
+}
+
+// PublishBatch decodes a batch from r and publishes every valid event individually to subject.
+// Invalid events are skipped and reported as *BatchError after all valid events have been published.
+func PublishBatch(b broker.Broker, subject string, r io.Reader, mode Mode) error {
+	dec := NewBatchDecoder(r)
+	batchErr := &BatchError{}
+
+	for {
+		e, err := dec.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		batchErr.Total++
+
+		var eventErr *EventError
+		if errors.As(err, &eventErr) {
+			batchErr.Errors = append(batchErr.Errors, eventErr)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := Publish(b, subject, e, mode); err != nil {
+			return fmt.Errorf("could not publish event '%s': %w", e.ID, err)
+		}
+	}
+
+	if len(batchErr.Errors) > 0 {
+		return batchErr
+	}
+	return nil
+}
+
+// unexpectedEOF converts io.EOF into io.ErrUnexpectedEOF, so a truncated batch can't be mistaken for its end.
+func unexpectedEOF(err error) error {
+	if errors.Is(err, io.EOF) {
+		return io.ErrUnexpectedEOF
+	}
+
+	return err
+}
+
+// validationError returns the violations of e as a single error, or nil if e is valid.
+func validationError(e *CloudEvent) error {
+	violations := e.violations()
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return errors.New(strings.Join(violations, ", "))
+}
+
+func decodeAll(dec *BatchDecoder) ([]*CloudEvent, error) {
+	var events []*CloudEvent
+	batchErr := &BatchError{}
+
+	for {
+		e, err := dec.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		batchErr.Total++
+
+		var eventErr *EventError
+		if errors.As(err, &eventErr) {
+			batchErr.Errors = append(batchErr.Errors, eventErr)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, e)
+	}
+
+	if len(batchErr.Errors) > 0 {
+		return events, batchErr
+	}
+	return events, nil
+}
@@ -0,0 +1,129 @@
+package cloudevents_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/OliverSchlueter/goutils/broker"
+	"github.com/OliverSchlueter/goutils/cloudevents"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mixedBatch = `[
+	{"specversion":"1.0","id":"1","type":"t","source":"/s"},
+	{"specversion":"1.0","id":"2","type":"t"},
+	{"specversion":"1.0","id":"3","type":"t","source":"/s","time":"yesterday"},
+	42,
+	{"specversion":"1.0","id":"5","type":"t","source":"/s"}
+]`
+
+func TestEncodeDecodeBatch(t *testing.T) {
+	events := []*cloudevents.CloudEvent{newOrderEvent(t), newOrderEvent(t)}
+
+	data, err := cloudevents.EncodeBatch(events)
+	require.NoError(t, err)
+
+	decoded, err := cloudevents.DecodeBatch(data)
+	require.NoError(t, err)
+	require.Len(t, decoded, 2)
+	assert.Equal(t, events[0].ID, decoded[0].ID)
+	assert.Equal(t, events[1].ID, decoded[1].ID)
+
+	data, err = cloudevents.EncodeBatch(nil)
+	require.NoError(t, err)
+	assert.Equal(t, "[]", string(data))
+
+	decoded, err = cloudevents.DecodeBatch(data)
+	require.NoError(t, err)
+	assert.Empty(t, decoded)
+}
+
+func TestDecodeBatch_InvalidEvents(t *testing.T) {
+	events, err := cloudevents.DecodeBatch([]byte(mixedBatch))
+
+	require.Len(t, events, 2)
+	assert.Equal(t, "1", events[0].ID)
+	assert.Equal(t, "5", events[1].ID)
+
+	var batchErr *cloudevents.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Equal(t, 5, batchErr.Total)
+	require.Len(t, batchErr.Errors, 3)
+	assert.Equal(t, 1, batchErr.Errors[0].Index)
+	assert.Equal(t, "2", batchErr.Errors[0].ID)
+	assert.Contains(t, batchErr.Errors[0].Error(), "'source' is required")
+	assert.Equal(t, 2, batchErr.Errors[1].Index)
+	assert.Equal(t, 3, batchErr.Errors[2].Index)
+
+	problem := batchErr.Problem()
+	assert.Equal(t, "InvalidCloudEvent", problem.Type)
+	assert.Contains(t, problem.Detail, "event #1 (id '2')")
+}
+
+func TestDecodeBatch_Malformed(t *testing.T) {
+	for _, data := range []string{``, `{}`, `[{"id":"1"}`, `[{"id":`} {
+		_, err := cloudevents.DecodeBatch([]byte(data))
+		assert.Error(t, err, data)
+
+		var batchErr *cloudevents.BatchError
+		assert.False(t, errors.As(err, &batchErr), data)
+	}
+}
+
+func TestBatchDecoder(t *testing.T) {
+	dec := cloudevents.NewBatchDecoder(strings.NewReader(mixedBatch))
+
+	var ids []string
+	var eventErrs int
+	for {
+		e, err := dec.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		var eventErr *cloudevents.EventError
+		if errors.As(err, &eventErr) {
+			eventErrs++
+			continue
+		}
+		require.NoError(t, err)
+		ids = append(ids, e.ID)
+	}
+
+	assert.Equal(t, []string{"1", "5"}, ids)
+	assert.Equal(t, 3, eventErrs)
+
+	_, err := dec.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestPublishBatch(t *testing.T) {
+	b := broker.NewFakeBroker()
+
+	var ids []string
+	err := b.Subscribe("events", func(msg *nats.Msg) {
+		e, err := cloudevents.FromNatsMsg(msg)
+		require.NoError(t, err)
+		ids = append(ids, e.ID)
+	})
+	require.NoError(t, err)
+
+	err = cloudevents.PublishBatch(b, "events", strings.NewReader(mixedBatch), cloudevents.ModeBinary)
+
+	var batchErr *cloudevents.BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Len(t, batchErr.Errors, 3)
+	assert.Equal(t, []string{"1", "5"}, ids)
+}
+
+func TestValidateBatch_Null(t *testing.T) {
+	batchErr := cloudevents.ValidateBatch([]*cloudevents.CloudEvent{newOrderEvent(t), nil})
+	require.NotNil(t, batchErr)
+	require.Len(t, batchErr.Errors, 1)
+	assert.Equal(t, 1, batchErr.Errors[0].Index)
+	assert.Contains(t, batchErr.Errors[0].Error(), "null")
+}
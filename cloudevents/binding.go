@@ -0,0 +1,111 @@
+package cloudevents
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mode is the content mode of a protocol binding.
+type Mode int
+
+const (
+	// ModeStructured encodes the whole event, including its attributes, in the message body.
+	ModeStructured Mode = iota
+	// ModeBinary encodes the attributes as headers and uses the event data as message body.
+	ModeBinary
+)
+
+const (
+	ContentTypeCloudEventsJSON      = "application/cloudevents+json"
+	ContentTypeCloudEventsBatchJSON = "application/cloudevents-batch+json"
+	ContentTypeCloudEventsCBOR      = "application/cloudevents+cbor"
+
+	headerPrefix      = "ce-"
+	headerContentType = "Content-Type"
+)
+
+// binaryAttributes returns all attributes except datacontenttype as strings, keyed by attribute name.
+func binaryAttributes(e *CloudEvent) map[string]string {
+	attrs := map[string]string{
+		"specversion": e.SpecVersion,
+		"id":          e.ID,
+		"type":        e.Type,
+		"source":      e.Source,
+	}
+	if e.Subject != "" {
+		attrs["subject"] = e.Subject
+	}
+	if !e.Time.IsZero() {
+		attrs["time"] = e.Time.Format(time.RFC3339Nano)
+	}
+	if e.DataSchema != "" {
+		attrs["dataschema"] = e.DataSchema
+	}
+
+	for name, value := range e.Extensions {
+		attrs[name] = extensionString(value)
+	}
+
+	return attrs
+}
+
+// fromBinaryAttributes creates an event from attributes keyed by their lower-cased, prefixed header names.
+// Extensions are decoded as strings, since binary mode doesn't carry type information.
+func fromBinaryAttributes(headers map[string]string, contentType string) (*CloudEvent, error) {
+	e := &CloudEvent{DataContentType: contentType}
+
+	for key, value := range headers {
+		name, ok := strings.CutPrefix(key, headerPrefix)
+		if !ok {
+			continue
+		}
+
+		switch name {
+		case "specversion":
+			e.SpecVersion = value
+		case "id":
+			e.ID = value
+		case "type":
+			e.Type = value
+		case "source":
+			e.Source = value
+		case "subject":
+			e.Subject = value
+		case "dataschema":
+			e.DataSchema = value
+		case "time":
+			t, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid attribute 'time': %w", err)
+			}
+			e.Time = t
+		default:
+			if err := e.SetExtension(name, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return e, nil
+}
+
+// extensionString returns the canonical string representation of an extension value.
+func extensionString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
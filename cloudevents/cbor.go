@@ -0,0 +1,182 @@
+package cloudevents
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// cborTagDateTime is the head of a text string tagged as RFC 3339 timestamp, see RFC 8949 section 3.4.1.
+const cborTagDateTime = 0xc0
+
+var (
+	// cborEnc sorts map keys, so the encoding is deterministic.
+	cborEnc = mustCBOREncMode(cbor.EncOptions{
+		Sort:    cbor.SortCoreDeterministic,
+		Time:    cbor.TimeRFC3339Nano,
+		TimeTag: cbor.EncTagRequired,
+	})
+	cborDec = mustCBORDecMode(cbor.DecOptions{
+		DupMapKey:   cbor.DupMapKeyEnforcedAPF,
+		IndefLength: cbor.IndefLengthForbidden,
+		TimeTag:     cbor.DecTagRequired,
+	})
+)
+
+func mustCBOREncMode(opts cbor.EncOptions) cbor.EncMode {
+	mode, err := opts.EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}
+
+func mustCBORDecMode(opts cbor.DecOptions) cbor.DecMode {
+	mode, err := opts.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}
+
+// cborCodec encodes events as a CBOR map of their attributes.
+// Data is encoded as byte string, timestamps as tagged RFC 3339 strings
+// and extensions with their native CBOR type.
+type cborCodec struct{}
+
+func (cborCodec) ContentType() string {
+	return ContentTypeCloudEventsCBOR
+}
+
+func (cborCodec) Encode(e *CloudEvent) ([]byte, error) {
+	attrs := map[string]any{
+		"specversion": e.SpecVersion,
+		"id":          e.ID,
+		"type":        e.Type,
+		"source":      e.Source,
+	}
+	if e.Subject != "" {
+		attrs["subject"] = e.Subject
+	}
+	if !e.Time.IsZero() {
+		attrs["time"] = e.Time
+	}
+	if e.DataContentType != "" {
+		attrs["datacontenttype"] = e.DataContentType
+	}
+	if e.DataSchema != "" {
+		attrs["dataschema"] = e.DataSchema
+	}
+	if len(e.Data) > 0 {
+		attrs["data"] = e.Data
+	}
+	for name, value := range e.Extensions {
+		if err := ValidateExtensionName(name); err != nil {
+			return nil, err
+		}
+		v, err := normalizeExtensionValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of extension '%s': %w", name, err)
+		}
+		attrs[name] = v
+	}
+
+	return cborEnc.Marshal(attrs)
+}
+
+func (cborCodec) Decode(data []byte) (*CloudEvent, error) {
+	var attrs map[string]cbor.RawMessage
+	if err := cborDec.Unmarshal(data, &attrs); err != nil {
+		return nil, err
+	}
+	if attrs == nil {
+		return nil, fmt.Errorf("cbor: expected map")
+	}
+
+	e := &CloudEvent{}
+	for name, raw := range attrs {
+		value, err := cborValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("cbor: invalid attribute '%s': %w", name, err)
+		}
+
+		if err := e.setCBORAttribute(name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
+
+// cborValue decodes a scalar value: text, bytes, integers, booleans or a tagged RFC 3339 timestamp.
+func cborValue(raw cbor.RawMessage) (any, error) {
+	// only RFC 3339 timestamps are tagged, epoch based ones would not survive a round trip
+	if raw[0]>>5 == 6 && raw[0] != cborTagDateTime {
+		return nil, fmt.Errorf("unsupported tag")
+	}
+
+	var value any
+	if err := cborDec.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+
+	switch v := value.(type) {
+	case string, []byte, bool, time.Time:
+		return v, nil
+	case uint64:
+		if v > math.MaxInt32 {
+			return nil, fmt.Errorf("integer %d is out of the int32 range", v)
+		}
+		return int32(v), nil
+	case int64:
+		if v < math.MinInt32 || v > math.MaxInt32 {
+			return nil, fmt.Errorf("integer %d is out of the int32 range", v)
+		}
+		return int32(v), nil
+	default:
+		return nil, fmt.Errorf("unsupported value of type %T", value)
+	}
+}
+
+func (e *CloudEvent) setCBORAttribute(name string, value any) error {
+	stringAttrs := map[string]*string{
+		"specversion":     &e.SpecVersion,
+		"id":              &e.ID,
+		"type":            &e.Type,
+		"source":          &e.Source,
+		"subject":         &e.Subject,
+		"datacontenttype": &e.DataContentType,
+		"dataschema":      &e.DataSchema,
+	}
+	if dst, ok := stringAttrs[name]; ok {
+		s, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("cbor: attribute '%s' must be a text string", name)
+		}
+		*dst = s
+		return nil
+	}
+
+	switch name {
+	case "time":
+		t, ok := value.(time.Time)
+		if !ok {
+			return fmt.Errorf("cbor: attribute 'time' must be a timestamp")
+		}
+		e.Time = t
+	case "data":
+		b, ok := value.([]byte)
+		if !ok {
+			return fmt.Errorf("cbor: attribute 'data' must be a byte string")
+		}
+		if len(b) > 0 {
+			e.Data = b
+		}
+	default:
+		return e.SetExtension(name, value)
+	}
+
+	return nil
+}
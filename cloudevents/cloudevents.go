@@ -3,14 +3,20 @@ package cloudevents
 
 import "time"
 
-const SpecVersion = "1.0.2"
+// SpecVersion is the value of the specversion attribute. All 1.0.x versions of the specification require exactly "1.0".
+const SpecVersion = "1.0"
 
 type CloudEvent struct {
-	SpecVersion     string    `json:"specversion"`
-	ID              string    `json:"id"`
-	Type            string    `json:"type"`
-	Subject         string    `json:"subject"`
-	Source          string    `json:"source"`
-	Time            time.Time `json:"time"`
-	DataContentType string    `json:"datacontenttype"`
+	SpecVersion     string
+	ID              string
+	Type            string
+	Subject         string
+	Source          string
+	Time            time.Time
+	DataContentType string
+	DataSchema      string
+	// Data is the event payload, encoded according to DataContentType. Use SetData and DataAs to access it.
+	Data []byte
+	// Extensions are the extension context attributes. Use SetExtension to add them.
+	Extensions map[string]any
 }
@@ -0,0 +1,194 @@
+package cloudevents_test
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/OliverSchlueter/goutils/cloudevents"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderCreated struct {
+	OrderID string `json:"orderId" xml:"orderId"`
+	Amount  int    `json:"amount" xml:"amount"`
+}
+
+func TestCloudEvent_MarshalJSON_Minimal(t *testing.T) {
+	event := cloudevents.CloudEvent{
+		SpecVersion: cloudevents.SpecVersion,
+		ID:          "123",
+		Type:        "com.example.order.created",
+		Source:      "/orders",
+	}
+
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"specversion": "1.0",
+		"id": "123",
+		"type": "com.example.order.created",
+		"source": "/orders"
+	}`, string(data))
+}
+
+func TestCloudEvent_JSONData(t *testing.T) {
+	event := cloudevents.CloudEvent{
+		SpecVersion: cloudevents.SpecVersion,
+		ID:          "123",
+		Type:        "com.example.order.created",
+		Source:      "/orders",
+		Time:        time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		DataSchema:  "https://example.com/schemas/order-created.json",
+	}
+	require.NoError(t, event.SetData(cloudevents.ContentTypeJSON, orderCreated{OrderID: "o-1", Amount: 42}))
+
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"specversion": "1.0",
+		"id": "123",
+		"type": "com.example.order.created",
+		"source": "/orders",
+		"time": "2025-01-02T03:04:05Z",
+		"datacontenttype": "application/json",
+		"dataschema": "https://example.com/schemas/order-created.json",
+		"data": {"orderId": "o-1", "amount": 42}
+	}`, string(data))
+
+	var decoded cloudevents.CloudEvent
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.True(t, event.Time.Equal(decoded.Time))
+	assert.Equal(t, event.DataSchema, decoded.DataSchema)
+
+	var payload orderCreated
+	require.NoError(t, decoded.DataAs(&payload))
+	assert.Equal(t, orderCreated{OrderID: "o-1", Amount: 42}, payload)
+}
+
+func TestCloudEvent_TextData(t *testing.T) {
+	event := cloudevents.CloudEvent{SpecVersion: cloudevents.SpecVersion, ID: "1", Type: "t", Source: "s"}
+	require.NoError(t, event.SetData(cloudevents.ContentTypeText, "hello"))
+
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"data":"hello"`)
+
+	var decoded cloudevents.CloudEvent
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	var s string
+	require.NoError(t, decoded.DataAs(&s))
+	assert.Equal(t, "hello", s)
+}
+
+func TestCloudEvent_XMLData(t *testing.T) {
+	event := cloudevents.CloudEvent{SpecVersion: cloudevents.SpecVersion, ID: "1", Type: "t", Source: "s"}
+	require.NoError(t, event.SetData(cloudevents.ContentTypeXML, orderCreated{OrderID: "o-1", Amount: 42}))
+
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	var decoded cloudevents.CloudEvent
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	var payload orderCreated
+	require.NoError(t, decoded.DataAs(&payload))
+	assert.Equal(t, orderCreated{OrderID: "o-1", Amount: 42}, payload)
+}
+
+func TestCloudEvent_BinaryData(t *testing.T) {
+	event := cloudevents.CloudEvent{SpecVersion: cloudevents.SpecVersion, ID: "1", Type: "t", Source: "s"}
+	require.NoError(t, event.SetData("application/octet-stream", []byte{0x00, 0xff, 0x10}))
+
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"data_base64":"AP8Q"`)
+	assert.NotContains(t, string(data), `"data":`)
+
+	var decoded cloudevents.CloudEvent
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, []byte{0x00, 0xff, 0x10}, decoded.Data)
+
+	assert.Error(t, event.SetData("application/octet-stream", 42))
+}
+
+func TestCloudEvent_BothDataAttributes(t *testing.T) {
+	var event cloudevents.CloudEvent
+	err := json.Unmarshal([]byte(`{"id":"1","data":"a","data_base64":"YQ=="}`), &event)
+	assert.Error(t, err)
+}
+
+func TestCloudEvent_UnmarshalJSON_Null(t *testing.T) {
+	var event cloudevents.CloudEvent
+	err := json.Unmarshal([]byte(`{
+		"specversion": "1.0",
+		"id": "1",
+		"type": "t",
+		"source": "s",
+		"subject": null,
+		"time": null,
+		"datacontenttype": null,
+		"dataschema": null,
+		"data": null,
+		"data_base64": null,
+		"traceid": null
+	}`), &event)
+	require.NoError(t, err)
+
+	assert.Empty(t, event.Subject)
+	assert.True(t, event.Time.IsZero())
+	assert.Empty(t, event.DataContentType)
+	assert.Empty(t, event.DataSchema)
+	assert.Nil(t, event.Data)
+	assert.Empty(t, event.Extensions)
+	assert.Nil(t, event.Validate())
+}
+
+func TestCloudEvent_Extensions(t *testing.T) {
+	event := cloudevents.CloudEvent{SpecVersion: cloudevents.SpecVersion, ID: "1", Type: "t", Source: "s"}
+	require.NoError(t, event.SetExtension("traceparent", "00-abc-def-01"))
+	require.NoError(t, event.SetExtension("sampled", true))
+	require.NoError(t, event.SetExtension("partition", 7))
+	require.NoError(t, event.SetExtension("origin", &url.URL{Scheme: "https", Host: "example.com"}))
+
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"specversion": "1.0",
+		"id": "1",
+		"type": "t",
+		"source": "s",
+		"traceparent": "00-abc-def-01",
+		"sampled": true,
+		"partition": 7,
+		"origin": "https://example.com"
+	}`, string(data))
+
+	var decoded cloudevents.CloudEvent
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	v, ok := decoded.Extension("partition")
+	assert.True(t, ok)
+	assert.Equal(t, int32(7), v)
+	v, _ = decoded.Extension("sampled")
+	assert.Equal(t, true, v)
+	v, _ = decoded.Extension("traceparent")
+	assert.Equal(t, "00-abc-def-01", v)
+}
+
+func TestCloudEvent_SetExtension_Invalid(t *testing.T) {
+	event := cloudevents.CloudEvent{}
+
+	assert.Error(t, event.SetExtension("", "v"))
+	assert.Error(t, event.SetExtension("Upper", "v"))
+	assert.Error(t, event.SetExtension("with-dash", "v"))
+	assert.Error(t, event.SetExtension("thisnameiswaytoolongforanextension", "v"))
+	assert.Error(t, event.SetExtension("subject", "v"))
+	assert.Error(t, event.SetExtension("ok", 1<<40))
+	assert.Error(t, event.SetExtension("ok", 1.5))
+	assert.Error(t, event.SetExtension("ok", struct{}{}))
+	assert.Empty(t, event.Extensions)
+}
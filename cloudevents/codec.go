@@ -0,0 +1,65 @@
+package cloudevents
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// Codec encodes and decodes events in structured mode.
+type Codec interface {
+	// ContentType is the media type of the encoded events, e.g. "application/cloudevents+json".
+	ContentType() string
+	Encode(e *CloudEvent) ([]byte, error)
+	Decode(data []byte) (*CloudEvent, error)
+}
+
+var (
+	// JSONCodec encodes events in the JSON event format.
+	JSONCodec Codec = jsonCodec{}
+	// CBORCodec encodes events as CBOR map, which is considerably smaller and faster to decode than JSON.
+	CBORCodec Codec = cborCodec{}
+)
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		ContentTypeCloudEventsJSON: JSONCodec,
+		ContentTypeCloudEventsCBOR: CBORCodec,
+	}
+)
+
+// RegisterCodec makes c available for decoding structured events of its content type.
+func RegisterCodec(c Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	codecs[mediaType(c.ContentType())] = c
+}
+
+// CodecFor returns the codec of a structured-mode content type.
+func CodecFor(contentType string) (Codec, bool) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	c, ok := codecs[mediaType(contentType)]
+	return c, ok
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return ContentTypeCloudEventsJSON
+}
+
+func (jsonCodec) Encode(e *CloudEvent) ([]byte, error) {
+	return json.Marshal(e)
+}
+
+func (jsonCodec) Decode(data []byte) (*CloudEvent, error) {
+	var e CloudEvent
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
@@ -0,0 +1,165 @@
+package cloudevents_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OliverSchlueter/goutils/broker"
+	"github.com/OliverSchlueter/goutils/cloudevents"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTelemetryEvent(t testing.TB) *cloudevents.CloudEvent {
+	event := newOrderEvent(t)
+	event.DataSchema = "https://example.com/schemas/order-created.json"
+	require.NoError(t, event.SetExtension("sampled", true))
+	require.NoError(t, event.SetExtension("offset", -17))
+	require.NoError(t, event.SetExtension("traceid", "4bf92f3577b34da6"))
+	require.NoError(t, event.SetExtension("payload", []byte{0x00, 0xff}))
+	require.NoError(t, event.SetExtension("expires", time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)))
+	return event
+}
+
+func TestCodecs_RoundTrip(t *testing.T) {
+	for _, codec := range []cloudevents.Codec{cloudevents.JSONCodec, cloudevents.CBORCodec} {
+		t.Run(codec.ContentType(), func(t *testing.T) {
+			event := newTelemetryEvent(t)
+
+			data, err := codec.Encode(event)
+			require.NoError(t, err)
+
+			decoded, err := codec.Decode(data)
+			require.NoError(t, err)
+			assert.Equal(t, event.ID, decoded.ID)
+			assert.Equal(t, event.Type, decoded.Type)
+			assert.Equal(t, event.Source, decoded.Source)
+			assert.Equal(t, event.Subject, decoded.Subject)
+			assert.Equal(t, event.DataSchema, decoded.DataSchema)
+			assert.Equal(t, event.DataContentType, decoded.DataContentType)
+			assert.True(t, event.Time.Equal(decoded.Time))
+			assert.JSONEq(t, string(event.Data), string(decoded.Data))
+			assert.Nil(t, decoded.Validate())
+
+			var order orderCreated
+			require.NoError(t, decoded.DataAs(&order))
+			assert.Equal(t, orderCreated{OrderID: "o-1", Amount: 42}, order)
+		})
+	}
+}
+
+func TestCBORCodec_Extensions(t *testing.T) {
+	event := newTelemetryEvent(t)
+
+	data, err := cloudevents.CBORCodec.Encode(event)
+	require.NoError(t, err)
+
+	// the encoding is deterministic
+	again, err := cloudevents.CBORCodec.Encode(event)
+	require.NoError(t, err)
+	assert.Equal(t, data, again)
+
+	jsonData, err := cloudevents.JSONCodec.Encode(event)
+	require.NoError(t, err)
+	assert.Less(t, len(data), len(jsonData))
+
+	decoded, err := cloudevents.CBORCodec.Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), decoded.Extensions["partition"])
+	assert.Equal(t, int32(-17), decoded.Extensions["offset"])
+	assert.Equal(t, true, decoded.Extensions["sampled"])
+	assert.Equal(t, "4bf92f3577b34da6", decoded.Extensions["traceid"])
+	assert.Equal(t, []byte{0x00, 0xff}, decoded.Extensions["payload"])
+	expires, ok := decoded.Extensions["expires"].(time.Time)
+	require.True(t, ok)
+	assert.True(t, expires.Equal(time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC)))
+}
+
+func TestCBORCodec_Decode_Invalid(t *testing.T) {
+	valid, err := cloudevents.CBORCodec.Encode(newOrderEvent(t))
+	require.NoError(t, err)
+
+	for name, data := range map[string][]byte{
+		"empty":              {},
+		"not a map":          {0x61, 'a'},
+		"truncated":          valid[:len(valid)-1],
+		"trailing bytes":     append(append([]byte{}, valid...), 0x00),
+		"indefinite length":  {0xbf, 0xff},
+		"huge map":           {0xbb, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		"huge string":        {0xa1, 0x7b, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff},
+		"non-text key":       {0xa1, 0x01, 0x01},
+		"wrong type of id":   {0xa1, 0x62, 'i', 'd', 0x01},
+		"duplicate key":      {0xa2, 0x62, 'i', 'd', 0x61, 'a', 0x62, 'i', 'd', 0x61, 'b'},
+		"out of int32 range": {0xa1, 0x61, 'x', 0x1a, 0xff, 0xff, 0xff, 0xff},
+		"unsupported tag":    {0xa1, 0x61, 'x', 0xc1, 0x01},
+		"nested map":         {0xa1, 0x61, 'x', 0xa0},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := cloudevents.CBORCodec.Decode(data)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCodecFor(t *testing.T) {
+	codec, ok := cloudevents.CodecFor("application/cloudevents+cbor")
+	require.True(t, ok)
+	assert.Equal(t, cloudevents.CBORCodec, codec)
+
+	codec, ok = cloudevents.CodecFor("application/cloudevents+json; charset=utf-8")
+	require.True(t, ok)
+	assert.Equal(t, cloudevents.JSONCodec, codec)
+
+	_, ok = cloudevents.CodecFor("application/cloudevents+avro")
+	assert.False(t, ok)
+}
+
+func TestPublishWithCodec(t *testing.T) {
+	b := broker.NewFakeBroker()
+
+	var received []*nats.Msg
+	require.NoError(t, b.Subscribe("telemetry", func(msg *nats.Msg) {
+		received = append(received, msg)
+	}))
+
+	event := newTelemetryEvent(t)
+	require.NoError(t, cloudevents.PublishWithCodec(b, "telemetry", event, cloudevents.CBORCodec))
+	require.Len(t, received, 1)
+	assert.Equal(t, cloudevents.ContentTypeCloudEventsCBOR, received[0].Header.Get("Content-Type"))
+
+	decoded, err := cloudevents.FromNatsMsg(received[0])
+	require.NoError(t, err)
+	assert.Equal(t, event.ID, decoded.ID)
+	assert.Equal(t, event.Data, decoded.Data)
+
+	assert.Error(t, cloudevents.PublishWithCodec(b, "telemetry", &cloudevents.CloudEvent{}, cloudevents.CBORCodec))
+}
+
+func FuzzCBORCodec(f *testing.F) {
+	minimal := cloudevents.New("com.example.ping", "/ping")
+	for _, event := range []*cloudevents.CloudEvent{minimal, newTelemetryEvent(f)} {
+		data, err := cloudevents.CBORCodec.Encode(event)
+		require.NoError(f, err)
+		f.Add(data)
+	}
+	f.Add([]byte{0xa1, 0x62, 'i', 'd', 0xc0, 0x61, 'x'})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		event, err := cloudevents.CBORCodec.Decode(data)
+		if err != nil {
+			return
+		}
+
+		encoded, err := cloudevents.CBORCodec.Encode(event)
+		require.NoError(t, err)
+
+		decoded, err := cloudevents.CBORCodec.Decode(encoded)
+		require.NoError(t, err)
+
+		// re-encoding a decoded event must be stable
+		again, err := cloudevents.CBORCodec.Encode(decoded)
+		require.NoError(t, err)
+		assert.Equal(t, encoded, again)
+	})
+}
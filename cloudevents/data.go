@@ -0,0 +1,112 @@
+package cloudevents
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"strings"
+)
+
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeXML  = "application/xml"
+	ContentTypeText = "text/plain"
+)
+
+// SetData encodes v according to contentType and sets it as the event's data.
+// JSON and XML content types are marshalled, all other content types require v to be a string or []byte.
+// A []byte is always used as is, as it is considered to be already encoded.
+func (e *CloudEvent) SetData(contentType string, v any) error {
+	data, err := encodeData(contentType, v)
+	if err != nil {
+		return err
+	}
+
+	e.DataContentType = contentType
+	e.Data = data
+	return nil
+}
+
+// DataAs decodes the event's data into v according to DataContentType.
+// For content types other than JSON and XML, v must be a *string or *[]byte.
+func (e *CloudEvent) DataAs(v any) error {
+	if len(e.Data) == 0 {
+		return fmt.Errorf("event has no data")
+	}
+
+	switch {
+	case isJSON(e.DataContentType):
+		if err := json.Unmarshal(e.Data, v); err != nil {
+			return fmt.Errorf("could not decode json data: %w", err)
+		}
+	case isXML(e.DataContentType):
+		if err := xml.Unmarshal(e.Data, v); err != nil {
+			return fmt.Errorf("could not decode xml data: %w", err)
+		}
+	default:
+		switch dst := v.(type) {
+		case *string:
+			*dst = string(e.Data)
+		case *[]byte:
+			*dst = append([]byte(nil), e.Data...)
+		default:
+			return fmt.Errorf("can't decode data of content type '%s' into %T", e.DataContentType, v)
+		}
+	}
+
+	return nil
+}
+
+func encodeData(contentType string, v any) ([]byte, error) {
+	if b, ok := v.([]byte); ok {
+		return b, nil
+	}
+
+	switch {
+	case isJSON(contentType):
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("could not encode json data: %w", err)
+		}
+		return data, nil
+	case isXML(contentType):
+		data, err := xml.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("could not encode xml data: %w", err)
+		}
+		return data, nil
+	}
+
+	if s, ok := v.(string); ok {
+		return []byte(s), nil
+	}
+
+	return nil, fmt.Errorf("can't encode %T as content type '%s'", v, contentType)
+}
+
+// mediaType returns the lower-cased media type of contentType without parameters.
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mt, _, _ = strings.Cut(contentType, ";")
+	}
+
+	return strings.ToLower(strings.TrimSpace(mt))
+}
+
+// isJSON reports whether contentType is a JSON media type. An empty content type implies JSON.
+func isJSON(contentType string) bool {
+	mt := mediaType(contentType)
+	return mt == "" || mt == ContentTypeJSON || mt == "text/json" || strings.HasSuffix(mt, "+json")
+}
+
+func isXML(contentType string) bool {
+	mt := mediaType(contentType)
+	return mt == ContentTypeXML || mt == "text/xml" || strings.HasSuffix(mt, "+xml")
+}
+
+// isText reports whether data of contentType can be represented as a string.
+func isText(contentType string) bool {
+	return strings.HasPrefix(mediaType(contentType), "text/") || isXML(contentType)
+}
@@ -0,0 +1,61 @@
+package cloudevents
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/OliverSchlueter/goutils/problems"
+)
+
+var (
+	TypeInvalidCloudEvent = problems.MustRegister(problems.Definition{
+		Type:        "InvalidCloudEvent",
+		Title:       "Invalid CloudEvent",
+		Status:      http.StatusBadRequest,
+		Description: "The event violates the CloudEvents specification, e.g. because a required attribute is missing.",
+	})
+	TypeUnknownEventType = problems.MustRegister(problems.Definition{
+		Type:        "UnknownEventType",
+		Title:       "Unknown event type",
+		Status:      http.StatusBadRequest,
+		Description: "There is no handler for events of the type and source.",
+	})
+	TypeCouldNotDecodeEventData = problems.MustRegister(problems.Definition{
+		Type:        "CouldNotDecodeEventData",
+		Title:       "Could not decode event data",
+		Status:      http.StatusBadRequest,
+		Description: "The data of the event does not match the structure expected for its type.",
+	})
+	TypeUnknownDataSchema = problems.MustRegister(problems.Definition{
+		Type:        "UnknownDataSchema",
+		Title:       "Unknown data schema",
+		Status:      http.StatusBadRequest,
+		Description: "The dataschema of the event is not registered for its type.",
+	})
+	TypeInvalidEventData = problems.MustRegister(problems.Definition{
+		Type:        "InvalidEventData",
+		Title:       "Invalid event data",
+		Status:      http.StatusBadRequest,
+		Description: "The data of the event does not match the JSON Schema of its dataschema.",
+	})
+)
+
+func InvalidEventProblem(violations []string) *problems.Problem {
+	return TypeInvalidCloudEvent.New("The event violates the CloudEvents specification: " + strings.Join(violations, "; "))
+}
+
+func UnknownEventTypeProblem(eventType, source string) *problems.Problem {
+	return TypeUnknownEventType.Newf("There is no handler for events of type '%s' from source '%s'.", eventType, source)
+}
+
+func CouldNotDecodeEventDataProblem(eventType string, err error) *problems.Problem {
+	return TypeCouldNotDecodeEventData.Newf("The data of the event of type '%s' could not be decoded: %s", eventType, err)
+}
+
+func UnknownDataSchemaProblem(eventType, dataSchema string) *problems.Problem {
+	return TypeUnknownDataSchema.Newf("The data schema '%s' of events of type '%s' is unknown.", dataSchema, eventType)
+}
+
+func InvalidEventDataProblem(eventType string, err error) *problems.Problem {
+	return TypeInvalidEventData.Newf("The data of the event of type '%s' does not match its schema: %s", eventType, err)
+}
@@ -0,0 +1,109 @@
+package cloudevents
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// reservedAttributes are the attribute names defined by the specification, which can't be used for extensions.
+var reservedAttributes = []string{
+	"specversion", "id", "type", "source", "subject", "time",
+	"datacontenttype", "dataschema", "data", "data_base64",
+}
+
+// ValidateExtensionName checks that name is a valid extension attribute name.
+// Names must consist of lower-case ASCII letters and digits, should not exceed 20 characters
+// and must not collide with the attributes defined by the specification.
+func ValidateExtensionName(name string) error {
+	if name == "" {
+		return fmt.Errorf("extension name must not be empty")
+	}
+	if len(name) > 20 {
+		return fmt.Errorf("extension name '%s' is longer than 20 characters", name)
+	}
+
+	for _, c := range name {
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+			return fmt.Errorf("extension name '%s' must only contain lower-case letters and digits", name)
+		}
+	}
+
+	if slices.Contains(reservedAttributes, name) {
+		return fmt.Errorf("extension name '%s' is reserved", name)
+	}
+
+	return nil
+}
+
+// SetExtension sets the extension attribute name to value.
+// Supported values are bool, integers within the int32 range, string, []byte, *url.URL and time.Time.
+func (e *CloudEvent) SetExtension(name string, value any) error {
+	if err := ValidateExtensionName(name); err != nil {
+		return err
+	}
+
+	v, err := normalizeExtensionValue(value)
+	if err != nil {
+		return fmt.Errorf("invalid value of extension '%s': %w", name, err)
+	}
+
+	if e.Extensions == nil {
+		e.Extensions = map[string]any{}
+	}
+	e.Extensions[name] = v
+	return nil
+}
+
+// Extension returns the value of the extension attribute name.
+func (e *CloudEvent) Extension(name string) (any, bool) {
+	v, ok := e.Extensions[name]
+	return v, ok
+}
+
+// normalizeExtensionValue converts value into the canonical type of its CloudEvents type system counterpart.
+func normalizeExtensionValue(value any) (any, error) {
+	switch v := value.(type) {
+	case bool, string, []byte, time.Time:
+		return v, nil
+	case *url.URL:
+		return v.String(), nil
+	case int:
+		return toInt32(int64(v))
+	case int32:
+		return v, nil
+	case int64:
+		return toInt32(v)
+	case float64:
+		// JSON numbers are decoded as float64
+		if v != math.Trunc(v) {
+			return nil, fmt.Errorf("%v is not an integer", v)
+		}
+		return toInt32(int64(v))
+	default:
+		return nil, fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+func toInt32(v int64) (any, error) {
+	if v < math.MinInt32 || v > math.MaxInt32 {
+		return nil, fmt.Errorf("%d is out of the int32 range", v)
+	}
+
+	return int32(v), nil
+}
+
+// extensionJSONValue returns the JSON representation of an extension value.
+func extensionJSONValue(value any) any {
+	switch v := value.(type) {
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return v
+	}
+}
@@ -0,0 +1,207 @@
+package cloudevents
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	ErrNotCloudEvent = errors.New("message is not a cloud event")
+	ErrBatch         = errors.New("message is a batch of cloud events")
+)
+
+// NewHTTPRequest creates a request carrying e according to the CloudEvents HTTP protocol binding.
+func NewHTTPRequest(ctx context.Context, method, url string, e *CloudEvent, mode Mode) (*http.Request, error) {
+	header, body, err := encodeHTTP(e, mode)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	copyHeader(req.Header, header)
+
+	return req, nil
+}
+
+// NewHTTPBatchRequest creates a request carrying events in batched mode.
+func NewHTTPBatchRequest(ctx context.Context, method, url string, events []*CloudEvent) (*http.Request, error) {
+	body, err := EncodeBatch(events)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(headerContentType, ContentTypeCloudEventsBatchJSON)
+
+	return req, nil
+}
+
+// WriteHTTPResponse writes e as response with the given status code.
+func WriteHTTPResponse(w http.ResponseWriter, status int, e *CloudEvent, mode Mode) error {
+	header, body, err := encodeHTTP(e, mode)
+	if err != nil {
+		return err
+	}
+
+	copyHeader(w.Header(), header)
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	return err
+}
+
+// WriteHTTPBatchResponse writes events in batched mode as response with the given status code.
+func WriteHTTPBatchResponse(w http.ResponseWriter, status int, events []*CloudEvent) error {
+	body, err := EncodeBatch(events)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set(headerContentType, ContentTypeCloudEventsBatchJSON)
+	w.WriteHeader(status)
+	_, err = w.Write(body)
+	return err
+}
+
+// FromHTTPRequest decodes a single event in binary or structured mode from r.
+// It returns ErrBatch for batched requests, use EventsFromHTTPRequest to accept them as well.
+func FromHTTPRequest(r *http.Request) (*CloudEvent, error) {
+	return decodeHTTP(r.Header, r.Body)
+}
+
+// FromHTTPResponse decodes a single event in binary or structured mode from resp.
+func FromHTTPResponse(resp *http.Response) (*CloudEvent, error) {
+	return decodeHTTP(resp.Header, resp.Body)
+}
+
+// EventsFromHTTPRequest decodes the events of r in any mode.
+// Batches are decoded with a BatchDecoder, so if some events are malformed or invalid,
+// the valid events are returned together with a *BatchError.
+func EventsFromHTTPRequest(r *http.Request) ([]*CloudEvent, error) {
+	if mediaType(r.Header.Get(headerContentType)) != ContentTypeCloudEventsBatchJSON {
+		e, err := FromHTTPRequest(r)
+		if err != nil {
+			return nil, err
+		}
+		return []*CloudEvent{e}, nil
+	}
+
+	return decodeAll(NewBatchDecoder(r.Body))
+}
+
+func encodeHTTP(e *CloudEvent, mode Mode) (http.Header, []byte, error) {
+	header := http.Header{}
+
+	if mode == ModeStructured {
+		data, err := JSONCodec.Encode(e)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not encode event: %w", err)
+		}
+
+		header.Set(headerContentType, JSONCodec.ContentType())
+		return header, data, nil
+	}
+
+	for name, value := range binaryAttributes(e) {
+		header.Set(headerPrefix+name, encodeHeaderValue(value))
+	}
+	if e.DataContentType != "" {
+		header.Set(headerContentType, e.DataContentType)
+	}
+
+	return header, e.Data, nil
+}
+
+func decodeHTTP(header http.Header, body io.Reader) (*CloudEvent, error) {
+	contentType := header.Get(headerContentType)
+
+	if header.Get(headerPrefix+"specversion") == "" {
+		if mediaType(contentType) == ContentTypeCloudEventsBatchJSON {
+			return nil, ErrBatch
+		}
+
+		codec, ok := CodecFor(contentType)
+		if !ok {
+			return nil, ErrNotCloudEvent
+		}
+
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return nil, fmt.Errorf("could not read body: %w", err)
+		}
+		e, err := codec.Decode(data)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode structured event: %w", err)
+		}
+		return e, nil
+	}
+
+	attrs := map[string]string{}
+	for key, values := range header {
+		key = strings.ToLower(key)
+		if len(values) == 0 || !strings.HasPrefix(key, headerPrefix) {
+			continue
+		}
+
+		value, err := decodeHeaderValue(values[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid header '%s': %w", key, err)
+		}
+		attrs[key] = value
+	}
+
+	e, err := fromBinaryAttributes(attrs, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read body: %w", err)
+	}
+	if len(data) > 0 {
+		e.Data = data
+	}
+
+	return e, nil
+}
+
+// encodeHeaderValue percent-encodes space, double-quote, percent and all characters outside printable ASCII.
+func encodeHeaderValue(value string) string {
+	var sb strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if c <= ' ' || c > '~' || c == '"' || c == '%' {
+			_, _ = fmt.Fprintf(&sb, "%%%02X", c)
+			continue
+		}
+		sb.WriteByte(c)
+	}
+
+	return sb.String()
+}
+
+func decodeHeaderValue(value string) (string, error) {
+	if !strings.Contains(value, "%") {
+		return value, nil
+	}
+
+	return url.PathUnescape(value)
+}
+
+func copyHeader(dst, src http.Header) {
+	for key, values := range src {
+		dst[key] = values
+	}
+}
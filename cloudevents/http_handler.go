@@ -0,0 +1,125 @@
+package cloudevents
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/OliverSchlueter/goutils/problems"
+)
+
+// HTTPHandler receives events over HTTP, e.g. as a webhook, and passes them to a handler function.
+// It implements the abuse protection handshake of the CloudEvents webhook specification.
+type HTTPHandler struct {
+	handle         func(r *http.Request, e *CloudEvent) *problems.Problem
+	allowedOrigins []string
+	allowedRate    int
+	maxBodyBytes   int64
+}
+
+type HTTPHandlerConfiguration struct {
+	// Handle is called for every valid event. A returned problem is written as response. It is required.
+	// For batched requests, processing stops at the first problem.
+	Handle func(r *http.Request, e *CloudEvent) *problems.Problem
+	// AllowedOrigins are the origins that are allowed to deliver events in the abuse protection handshake.
+	// "*" allows all origins. If empty, the handshake is always rejected.
+	AllowedOrigins []string
+	// AllowedRate is the number of requests per minute the sender is allowed to send. Zero means unlimited.
+	AllowedRate int
+	// MaxBodyBytes limits the size of the request body. Defaults to 4 MiB.
+	MaxBodyBytes int64
+}
+
+// NewHTTPHandler creates a handler for cfg. It panics if cfg.Handle is nil.
+func NewHTTPHandler(cfg HTTPHandlerConfiguration) *HTTPHandler {
+	if cfg.Handle == nil {
+		panic("cloudevents: HTTPHandlerConfiguration.Handle must not be nil")
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = 4 * 1024 * 1024
+	}
+
+	return &HTTPHandler{
+		handle:         cfg.Handle,
+		allowedOrigins: cfg.AllowedOrigins,
+		allowedRate:    cfg.AllowedRate,
+		maxBodyBytes:   cfg.MaxBodyBytes,
+	}
+}
+
+func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodOptions:
+		h.handleValidation(w, r)
+	case http.MethodPost:
+		h.handleEvents(w, r)
+	default:
+		problems.MethodNotAllowed(r.Method, []string{http.MethodPost, http.MethodOptions}).WriteToHTTP(w)
+	}
+}
+
+// handleValidation answers the abuse protection handshake.
+func (h *HTTPHandler) handleValidation(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("WebHook-Request-Origin")
+	if origin == "" {
+		w.Header().Set("Allow", "POST, OPTIONS")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if !slices.Contains(h.allowedOrigins, "*") && !slices.Contains(h.allowedOrigins, origin) {
+		problems.Forbidden().WriteToHTTP(w)
+		return
+	}
+
+	rate := "*"
+	if h.allowedRate > 0 {
+		rate = strconv.Itoa(h.allowedRate)
+	}
+
+	w.Header().Set("Allow", "POST")
+	w.Header().Set("WebHook-Allowed-Origin", origin)
+	w.Header().Set("WebHook-Allowed-Rate", rate)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *HTTPHandler) handleEvents(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxBodyBytes)
+
+	events, err := EventsFromHTTPRequest(r)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		problems.ContentTooLarge(h.maxBodyBytes).WriteToHTTP(w)
+		return
+	}
+	if errors.Is(err, ErrNotCloudEvent) {
+		problems.WrongContentType(ContentTypeCloudEventsJSON, r.Header.Get(headerContentType)).WriteToHTTP(w)
+		return
+	}
+	var batchErr *BatchError
+	if errors.As(err, &batchErr) {
+		batchErr.Problem().WriteToHTTP(w)
+		return
+	}
+	if err != nil {
+		problem := problems.CouldNotDecodeBody()
+		problem.Detail = "The request body could not be decoded as cloud event: " + err.Error()
+		problem.WriteToHTTP(w)
+		return
+	}
+
+	if batchErr := ValidateBatch(events); batchErr != nil {
+		batchErr.Problem().WriteToHTTP(w)
+		return
+	}
+
+	for _, e := range events {
+		if problem := h.handle(r, e); problem != nil {
+			problem.WriteToHTTP(w)
+			return
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
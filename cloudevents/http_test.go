@@ -0,0 +1,219 @@
+package cloudevents_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OliverSchlueter/goutils/cloudevents"
+	"github.com/OliverSchlueter/goutils/problems"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPRequest_Binary(t *testing.T) {
+	event := newOrderEvent(t)
+	event.Subject = "order \"1\" 100% ä"
+
+	req, err := cloudevents.NewHTTPRequest(context.Background(), http.MethodPost, "http://example.com", event, cloudevents.ModeBinary)
+	require.NoError(t, err)
+	assert.Equal(t, event.ID, req.Header.Get("ce-id"))
+	assert.Equal(t, "order%20%221%22%20100%25%20%C3%A4", req.Header.Get("ce-subject"))
+	assert.Equal(t, cloudevents.ContentTypeJSON, req.Header.Get("Content-Type"))
+
+	decoded, err := cloudevents.FromHTTPRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, event.ID, decoded.ID)
+	assert.Equal(t, event.Subject, decoded.Subject)
+	assert.Equal(t, event.Data, decoded.Data)
+}
+
+func TestNewHTTPRequest_Structured(t *testing.T) {
+	event := newOrderEvent(t)
+
+	req, err := cloudevents.NewHTTPRequest(context.Background(), http.MethodPost, "http://example.com", event, cloudevents.ModeStructured)
+	require.NoError(t, err)
+	assert.Equal(t, cloudevents.ContentTypeCloudEventsJSON, req.Header.Get("Content-Type"))
+	assert.Empty(t, req.Header.Get("ce-id"))
+
+	decoded, err := cloudevents.FromHTTPRequest(req)
+	require.NoError(t, err)
+	assert.Equal(t, event.ID, decoded.ID)
+	assert.JSONEq(t, string(event.Data), string(decoded.Data))
+}
+
+func TestNewHTTPBatchRequest(t *testing.T) {
+	events := []*cloudevents.CloudEvent{newOrderEvent(t), newOrderEvent(t)}
+
+	req, err := cloudevents.NewHTTPBatchRequest(context.Background(), http.MethodPost, "http://example.com", events)
+	require.NoError(t, err)
+	assert.Equal(t, cloudevents.ContentTypeCloudEventsBatchJSON, req.Header.Get("Content-Type"))
+
+	decoded, err := cloudevents.EventsFromHTTPRequest(req)
+	require.NoError(t, err)
+	require.Len(t, decoded, 2)
+	assert.Equal(t, events[0].ID, decoded[0].ID)
+	assert.Equal(t, events[1].ID, decoded[1].ID)
+}
+
+func TestFromHTTPRequest_Invalid(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	_, err := cloudevents.FromHTTPRequest(req)
+	assert.ErrorIs(t, err, cloudevents.ErrNotCloudEvent)
+
+	req = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[]`))
+	req.Header.Set("Content-Type", cloudevents.ContentTypeCloudEventsBatchJSON)
+	_, err = cloudevents.FromHTTPRequest(req)
+	assert.ErrorIs(t, err, cloudevents.ErrBatch)
+}
+
+func TestWriteHTTPResponse(t *testing.T) {
+	event := newOrderEvent(t)
+
+	rr := httptest.NewRecorder()
+	require.NoError(t, cloudevents.WriteHTTPResponse(rr, http.StatusOK, event, cloudevents.ModeBinary))
+
+	decoded, err := cloudevents.FromHTTPResponse(rr.Result())
+	require.NoError(t, err)
+	assert.Equal(t, event.ID, decoded.ID)
+	assert.Equal(t, event.Data, decoded.Data)
+
+	rr = httptest.NewRecorder()
+	require.NoError(t, cloudevents.WriteHTTPBatchResponse(rr, http.StatusOK, []*cloudevents.CloudEvent{event}))
+	assert.Equal(t, cloudevents.ContentTypeCloudEventsBatchJSON, rr.Header().Get("Content-Type"))
+}
+
+func newTestHTTPHandler(received *[]*cloudevents.CloudEvent) *cloudevents.HTTPHandler {
+	return cloudevents.NewHTTPHandler(cloudevents.HTTPHandlerConfiguration{
+		Handle: func(r *http.Request, e *cloudevents.CloudEvent) *problems.Problem {
+			if e.Type == "com.example.rejected" {
+				return problems.Forbidden()
+			}
+			*received = append(*received, e)
+			return nil
+		},
+		AllowedOrigins: []string{"partner.example.com"},
+		AllowedRate:    120,
+	})
+}
+
+func TestHTTPHandler_Events(t *testing.T) {
+	var received []*cloudevents.CloudEvent
+	handler := newTestHTTPHandler(&received)
+
+	for _, mode := range []cloudevents.Mode{cloudevents.ModeBinary, cloudevents.ModeStructured} {
+		req, err := cloudevents.NewHTTPRequest(context.Background(), http.MethodPost, "/", newOrderEvent(t), mode)
+		require.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNoContent, rr.Code)
+	}
+
+	req, err := cloudevents.NewHTTPBatchRequest(context.Background(), http.MethodPost, "/", []*cloudevents.CloudEvent{newOrderEvent(t), newOrderEvent(t)})
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	assert.Len(t, received, 4)
+}
+
+func TestHTTPHandler_Problems(t *testing.T) {
+	var received []*cloudevents.CloudEvent
+	handler := newTestHTTPHandler(&received)
+
+	tests := []struct {
+		name        string
+		method      string
+		contentType string
+		body        string
+		status      int
+		problemType string
+	}{
+		{"wrong method", http.MethodGet, "", "", http.StatusMethodNotAllowed, "MethodNotAllowed"},
+		{"not an event", http.MethodPost, "application/json", `{}`, http.StatusUnsupportedMediaType, "WrongContentType"},
+		{"malformed", http.MethodPost, cloudevents.ContentTypeCloudEventsJSON, `{`, http.StatusBadRequest, "CouldNotDecodeBody"},
+		{"invalid", http.MethodPost, cloudevents.ContentTypeCloudEventsJSON, `{"specversion":"1.0","id":"1"}`, http.StatusBadRequest, "InvalidCloudEvent"},
+		{"null in batch", http.MethodPost, cloudevents.ContentTypeCloudEventsBatchJSON, `[null]`, http.StatusBadRequest, "InvalidCloudEvent"},
+		{"malformed batch", http.MethodPost, cloudevents.ContentTypeCloudEventsBatchJSON, `[{`, http.StatusBadRequest, "CouldNotDecodeBody"},
+		{"rejected", http.MethodPost, cloudevents.ContentTypeCloudEventsJSON, `{"specversion":"1.0","id":"1","type":"com.example.rejected","source":"/"}`, http.StatusForbidden, "Forbidden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.status, rr.Code)
+			assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+
+			var problem problems.Problem
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+			assert.Equal(t, tt.problemType, problem.Type)
+		})
+	}
+
+	assert.Empty(t, received)
+}
+
+func TestHTTPHandler_WebhookValidation(t *testing.T) {
+	handler := newTestHTTPHandler(nil)
+
+	req := httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set("WebHook-Request-Origin", "partner.example.com")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "partner.example.com", rr.Header().Get("WebHook-Allowed-Origin"))
+	assert.Equal(t, "120", rr.Header().Get("WebHook-Allowed-Rate"))
+	assert.Equal(t, "POST", rr.Header().Get("Allow"))
+
+	req = httptest.NewRequest(http.MethodOptions, "/", nil)
+	req.Header.Set("WebHook-Request-Origin", "evil.example.com")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.Empty(t, rr.Header().Get("WebHook-Allowed-Origin"))
+}
+
+func TestHTTPHandler_ContentTooLarge(t *testing.T) {
+	handler := cloudevents.NewHTTPHandler(cloudevents.HTTPHandlerConfiguration{
+		Handle: func(r *http.Request, e *cloudevents.CloudEvent) *problems.Problem {
+			return nil
+		},
+		MaxBodyBytes: 64,
+	})
+
+	for _, contentType := range []string{cloudevents.ContentTypeCloudEventsJSON, cloudevents.ContentTypeCloudEventsBatchJSON} {
+		body := `{"specversion":"1.0","id":"1","type":"com.example.order","source":"/","subject":"` + strings.Repeat("a", 100) + `"}`
+		if contentType == cloudevents.ContentTypeCloudEventsBatchJSON {
+			body = "[" + body + "]"
+		}
+
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		req.Header.Set("Content-Type", contentType)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code, contentType)
+		var problem problems.Problem
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+		assert.Equal(t, "ContentTooLarge", problem.Type)
+	}
+}
+
+func TestNewHTTPHandler_NilHandle(t *testing.T) {
+	assert.Panics(t, func() {
+		cloudevents.NewHTTPHandler(cloudevents.HTTPHandlerConfiguration{})
+	})
+}
@@ -0,0 +1,150 @@
+package cloudevents
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// MarshalJSON encodes the event in the JSON event format.
+// Optional attributes are omitted when empty and extensions are added as top-level attributes.
+// JSON data is embedded as is, textual data as a string and all other data as "data_base64".
+func (e CloudEvent) MarshalJSON() ([]byte, error) {
+	m := map[string]any{
+		"specversion": e.SpecVersion,
+		"id":          e.ID,
+		"type":        e.Type,
+		"source":      e.Source,
+	}
+	if e.Subject != "" {
+		m["subject"] = e.Subject
+	}
+	if !e.Time.IsZero() {
+		m["time"] = e.Time.Format(time.RFC3339Nano)
+	}
+	if e.DataContentType != "" {
+		m["datacontenttype"] = e.DataContentType
+	}
+	if e.DataSchema != "" {
+		m["dataschema"] = e.DataSchema
+	}
+
+	for name, value := range e.Extensions {
+		if err := ValidateExtensionName(name); err != nil {
+			return nil, err
+		}
+		m[name] = extensionJSONValue(value)
+	}
+
+	if len(e.Data) > 0 {
+		switch {
+		case isJSON(e.DataContentType):
+			if !json.Valid(e.Data) {
+				return nil, fmt.Errorf("data is not valid json")
+			}
+			m["data"] = json.RawMessage(e.Data)
+		case isText(e.DataContentType):
+			m["data"] = string(e.Data)
+		default:
+			m["data_base64"] = base64.StdEncoding.EncodeToString(e.Data)
+		}
+	}
+
+	return json.Marshal(m)
+}
+
+// UnmarshalJSON decodes an event in the JSON event format.
+// Unknown attributes are decoded as extensions. Attributes with null values are treated as absent.
+func (e *CloudEvent) UnmarshalJSON(data []byte) error {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	// null attributes are treated as absent
+	for name, raw := range m {
+		if bytes.Equal(raw, []byte("null")) {
+			delete(m, name)
+		}
+	}
+
+	event := CloudEvent{}
+	attrs := map[string]*string{
+		"specversion":     &event.SpecVersion,
+		"id":              &event.ID,
+		"type":            &event.Type,
+		"source":          &event.Source,
+		"subject":         &event.Subject,
+		"datacontenttype": &event.DataContentType,
+		"dataschema":      &event.DataSchema,
+	}
+	for name, dst := range attrs {
+		raw, ok := m[name]
+		if !ok {
+			continue
+		}
+		delete(m, name)
+
+		if err := json.Unmarshal(raw, dst); err != nil {
+			return fmt.Errorf("invalid attribute '%s': %w", name, err)
+		}
+	}
+
+	if raw, ok := m["time"]; ok {
+		delete(m, "time")
+
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return fmt.Errorf("invalid attribute 'time': %w", err)
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return fmt.Errorf("invalid attribute 'time': %w", err)
+		}
+		event.Time = t
+	}
+
+	if raw, ok := m["data_base64"]; ok {
+		delete(m, "data_base64")
+
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return fmt.Errorf("invalid attribute 'data_base64': %w", err)
+		}
+		d, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return fmt.Errorf("invalid attribute 'data_base64': %w", err)
+		}
+		event.Data = d
+	}
+
+	if raw, ok := m["data"]; ok {
+		delete(m, "data")
+
+		if event.Data != nil {
+			return fmt.Errorf("event must not contain both 'data' and 'data_base64'")
+		}
+
+		// textual data of non-JSON content types is encoded as a JSON string
+		var s string
+		if !isJSON(event.DataContentType) && json.Unmarshal(raw, &s) == nil {
+			event.Data = []byte(s)
+		} else {
+			event.Data = raw
+		}
+	}
+
+	for name, raw := range m {
+		var value any
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return fmt.Errorf("invalid extension '%s': %w", name, err)
+		}
+		if err := event.SetExtension(name, value); err != nil {
+			return err
+		}
+	}
+
+	*e = event
+	return nil
+}
@@ -0,0 +1,146 @@
+package cloudevents
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/OliverSchlueter/goutils/broker"
+	"github.com/OliverSchlueter/goutils/sloki"
+	"github.com/nats-io/nats.go"
+)
+
+// ToNatsMsg encodes e as a NATS message for subject according to the CloudEvents NATS protocol binding.
+// Structured mode uses the JSON event format, see ToNatsMsgWithCodec for other formats.
+func ToNatsMsg(subject string, e *CloudEvent, mode Mode) (*nats.Msg, error) {
+	if mode == ModeStructured {
+		return ToNatsMsgWithCodec(subject, e, JSONCodec)
+	}
+
+	msg := nats.NewMsg(subject)
+	for name, value := range binaryAttributes(e) {
+		msg.Header.Set(headerPrefix+name, value)
+	}
+	if e.DataContentType != "" {
+		msg.Header.Set(headerContentType, e.DataContentType)
+	}
+	msg.Data = e.Data
+
+	return msg, nil
+}
+
+// ToNatsMsgWithCodec encodes e as a structured-mode NATS message for subject using codec.
+func ToNatsMsgWithCodec(subject string, e *CloudEvent, codec Codec) (*nats.Msg, error) {
+	data, err := codec.Encode(e)
+	if err != nil {
+		return nil, fmt.Errorf("could not encode event: %w", err)
+	}
+
+	msg := nats.NewMsg(subject)
+	msg.Header.Set(headerContentType, codec.ContentType())
+	msg.Data = data
+	return msg, nil
+}
+
+// FromNatsMsg decodes an event from a NATS message.
+// Messages with a "ce-specversion" header are decoded in binary mode, all others in structured mode
+// using the codec registered for their content type. Messages without content type are decoded as JSON.
+func FromNatsMsg(msg *nats.Msg) (*CloudEvent, error) {
+	headers := map[string]string{}
+	for key, values := range msg.Header {
+		if len(values) > 0 {
+			headers[strings.ToLower(key)] = values[0]
+		}
+	}
+
+	if _, ok := headers[headerPrefix+"specversion"]; !ok {
+		codec := JSONCodec
+		if contentType := headers[strings.ToLower(headerContentType)]; contentType != "" {
+			c, ok := CodecFor(contentType)
+			if !ok {
+				return nil, fmt.Errorf("%w: unsupported content type '%s'", ErrNotCloudEvent, contentType)
+			}
+			codec = c
+		}
+
+		e, err := codec.Decode(msg.Data)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode structured event: %w", err)
+		}
+		return e, nil
+	}
+
+	e, err := fromBinaryAttributes(headers, headers[strings.ToLower(headerContentType)])
+	if err != nil {
+		return nil, err
+	}
+	if len(msg.Data) > 0 {
+		e.Data = msg.Data
+	}
+
+	return e, nil
+}
+
+// Publish validates e and publishes it to subject.
+func Publish(b broker.Broker, subject string, e *CloudEvent, mode Mode) error {
+	if problem := e.Validate(); problem != nil {
+		return problem
+	}
+
+	msg, err := ToNatsMsg(subject, e, mode)
+	if err != nil {
+		return err
+	}
+
+	return broker.PublishMsg(b, msg)
+}
+
+// PublishWithCodec validates e and publishes it to subject in structured mode using codec.
+func PublishWithCodec(b broker.Broker, subject string, e *CloudEvent, codec Codec) error {
+	if problem := e.Validate(); problem != nil {
+		return problem
+	}
+
+	msg, err := ToNatsMsgWithCodec(subject, e, codec)
+	if err != nil {
+		return err
+	}
+
+	return broker.PublishMsg(b, msg)
+}
+
+// Subscribe subscribes to events on subject and decodes their data into T.
+// Messages that are not valid events or whose data can't be decoded are logged and dropped.
+func Subscribe[T any](b broker.Broker, subject string, handler func(e *CloudEvent, data T)) error {
+	return b.Subscribe(subject, eventHandler(subject, handler))
+}
+
+// SubscribeQueue is like Subscribe, but each event is only delivered to one subscriber of the queue.
+func SubscribeQueue[T any](b broker.Broker, subject, queue string, handler func(e *CloudEvent, data T)) error {
+	return b.SubscribeQueue(subject, queue, eventHandler(subject, handler))
+}
+
+func eventHandler[T any](subject string, handler func(e *CloudEvent, data T)) nats.MsgHandler {
+	return func(msg *nats.Msg) {
+		e, err := FromNatsMsg(msg)
+		if err != nil {
+			slog.Warn("Could not decode event", sloki.WrapError(err), slog.String("subject", subject))
+			return
+		}
+
+		if problem := e.Validate(); problem != nil {
+			slog.Warn("Received invalid event", slog.String("detail", problem.Detail), slog.String("subject", subject))
+			return
+		}
+
+		var data T
+		if len(e.Data) > 0 {
+			if err := e.DataAs(&data); err != nil {
+				slog.Warn("Could not decode event data", sloki.WrapError(err), slog.String("subject", subject), slog.String("type", e.Type))
+				return
+			}
+		}
+
+		handler(e, data)
+	}
+}
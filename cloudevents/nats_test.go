@@ -0,0 +1,121 @@
+package cloudevents_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/OliverSchlueter/goutils/broker"
+	"github.com/OliverSchlueter/goutils/cloudevents"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOrderEvent(t testing.TB) *cloudevents.CloudEvent {
+	event := cloudevents.New("com.example.order.created", "/orders")
+	event.Subject = "o-1"
+	require.NoError(t, event.SetData(cloudevents.ContentTypeJSON, orderCreated{OrderID: "o-1", Amount: 42}))
+	require.NoError(t, event.SetExtension("partition", 3))
+	return event
+}
+
+func TestToNatsMsg_Structured(t *testing.T) {
+	event := newOrderEvent(t)
+
+	msg, err := cloudevents.ToNatsMsg("orders", event, cloudevents.ModeStructured)
+	require.NoError(t, err)
+	assert.Equal(t, "orders", msg.Subject)
+	assert.Equal(t, cloudevents.ContentTypeCloudEventsJSON, msg.Header.Get("Content-Type"))
+	assert.Empty(t, msg.Header.Get("ce-specversion"))
+
+	var decoded map[string]any
+	require.NoError(t, json.Unmarshal(msg.Data, &decoded))
+	assert.Equal(t, event.ID, decoded["id"])
+	assert.Equal(t, map[string]any{"orderId": "o-1", "amount": float64(42)}, decoded["data"])
+
+	roundTrip, err := cloudevents.FromNatsMsg(msg)
+	require.NoError(t, err)
+	assert.Equal(t, event.ID, roundTrip.ID)
+	assert.Equal(t, int32(3), roundTrip.Extensions["partition"])
+	assert.JSONEq(t, string(event.Data), string(roundTrip.Data))
+}
+
+func TestToNatsMsg_Binary(t *testing.T) {
+	event := newOrderEvent(t)
+
+	msg, err := cloudevents.ToNatsMsg("orders", event, cloudevents.ModeBinary)
+	require.NoError(t, err)
+	assert.Equal(t, cloudevents.SpecVersion, msg.Header.Get("ce-specversion"))
+	assert.Equal(t, event.ID, msg.Header.Get("ce-id"))
+	assert.Equal(t, "com.example.order.created", msg.Header.Get("ce-type"))
+	assert.Equal(t, "/orders", msg.Header.Get("ce-source"))
+	assert.Equal(t, "o-1", msg.Header.Get("ce-subject"))
+	assert.Equal(t, event.Time.Format(time.RFC3339Nano), msg.Header.Get("ce-time"))
+	assert.Equal(t, "3", msg.Header.Get("ce-partition"))
+	assert.Equal(t, cloudevents.ContentTypeJSON, msg.Header.Get("Content-Type"))
+	assert.Equal(t, event.Data, msg.Data)
+
+	roundTrip, err := cloudevents.FromNatsMsg(msg)
+	require.NoError(t, err)
+	assert.Equal(t, event.ID, roundTrip.ID)
+	assert.Equal(t, event.Type, roundTrip.Type)
+	assert.Equal(t, event.Source, roundTrip.Source)
+	assert.Equal(t, event.Subject, roundTrip.Subject)
+	assert.True(t, event.Time.Equal(roundTrip.Time))
+	assert.Equal(t, event.DataContentType, roundTrip.DataContentType)
+	assert.Equal(t, "3", roundTrip.Extensions["partition"])
+	assert.Equal(t, event.Data, roundTrip.Data)
+}
+
+func TestFromNatsMsg_Invalid(t *testing.T) {
+	_, err := cloudevents.FromNatsMsg(&nats.Msg{Data: []byte("not json")})
+	assert.Error(t, err)
+
+	msg := nats.NewMsg("orders")
+	msg.Header.Set("ce-specversion", "1.0")
+	msg.Header.Set("ce-time", "yesterday")
+	_, err = cloudevents.FromNatsMsg(msg)
+	assert.Error(t, err)
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	for _, mode := range []cloudevents.Mode{cloudevents.ModeStructured, cloudevents.ModeBinary} {
+		b := broker.NewFakeBroker()
+
+		received := make(chan orderCreated, 1)
+		err := cloudevents.Subscribe(b, "orders", func(e *cloudevents.CloudEvent, data orderCreated) {
+			assert.Equal(t, "com.example.order.created", e.Type)
+			received <- data
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, cloudevents.Publish(b, "orders", newOrderEvent(t), mode))
+
+		select {
+		case data := <-received:
+			assert.Equal(t, orderCreated{OrderID: "o-1", Amount: 42}, data)
+		case <-time.After(time.Second):
+			t.Fatal("Timed out waiting for event")
+		}
+	}
+}
+
+func TestPublish_Invalid(t *testing.T) {
+	err := cloudevents.Publish(broker.NewFakeBroker(), "orders", &cloudevents.CloudEvent{}, cloudevents.ModeBinary)
+	assert.ErrorContains(t, err, "'id' is required")
+}
+
+func TestSubscribe_DropsInvalid(t *testing.T) {
+	b := broker.NewFakeBroker()
+
+	called := false
+	err := cloudevents.Subscribe(b, "orders", func(e *cloudevents.CloudEvent, data orderCreated) {
+		called = true
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, b.Publish("orders", []byte("not an event")))
+	require.NoError(t, b.Publish("orders", []byte(`{"specversion":"1.0","id":"1","type":"t","source":"s","data":"not an order"}`)))
+	assert.False(t, called)
+}
@@ -0,0 +1,142 @@
+package cloudevents
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/OliverSchlueter/goutils/problems"
+	"github.com/OliverSchlueter/goutils/sloki"
+	"github.com/nats-io/nats.go"
+)
+
+// Router dispatches events to the handler registered for their type and source.
+type Router struct {
+	mu      sync.RWMutex
+	routes  []route
+	schemas *SchemaRegistry
+}
+
+type route struct {
+	typePattern   string
+	sourcePattern string
+	handle        func(ctx context.Context, e *CloudEvent) *problems.Problem
+}
+
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// UseSchemas makes the router validate the data of all events against registry
+// and upcast it to the current version before dispatching them.
+func (r *Router) UseSchemas(registry *SchemaRegistry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.schemas = registry
+}
+
+// Route registers handler for events whose type matches typePattern, decoding their data into T.
+// See RouteSource for the pattern syntax.
+func Route[T any](r *Router, typePattern string, handler func(ctx context.Context, e *CloudEvent, data T) *problems.Problem) {
+	RouteSource(r, typePattern, "*", handler)
+}
+
+// RouteSource registers handler for events whose type and source match the patterns, decoding their data into T.
+// A pattern is either "*" to match everything, a prefix ending with "*" (e.g. "com.example.order.*")
+// or an exact value. Routes are matched in registration order, so specific routes must be registered first.
+func RouteSource[T any](r *Router, typePattern, sourcePattern string, handler func(ctx context.Context, e *CloudEvent, data T) *problems.Problem) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.routes = append(r.routes, route{
+		typePattern:   typePattern,
+		sourcePattern: sourcePattern,
+		handle: func(ctx context.Context, e *CloudEvent) *problems.Problem {
+			var data T
+			if len(e.Data) > 0 {
+				if err := e.DataAs(&data); err != nil {
+					return CouldNotDecodeEventDataProblem(e.Type, err)
+				}
+			}
+
+			return handler(ctx, e, data)
+		},
+	})
+}
+
+// Dispatch passes e to the first matching handler and returns its problem.
+// It returns an UnknownEventTypeProblem if no route matches.
+func (r *Router) Dispatch(ctx context.Context, e *CloudEvent) *problems.Problem {
+	// routes are only appended, so the snapshot stays valid without holding the lock,
+	// which would deadlock handlers registering routes and block registrations until slow handlers return
+	r.mu.RLock()
+	routes, schemas := r.routes, r.schemas
+	r.mu.RUnlock()
+
+	if schemas != nil {
+		if problem := schemas.Validate(e); problem != nil {
+			return problem
+		}
+
+		upcasted, err := schemas.Upcast(e)
+		var problem *problems.Problem
+		if errors.As(err, &problem) {
+			return problem
+		}
+		if err != nil {
+			return CouldNotDecodeEventDataProblem(e.Type, err)
+		}
+		e = upcasted
+	}
+
+	for _, rt := range routes {
+		if matchPattern(rt.typePattern, e.Type) && matchPattern(rt.sourcePattern, e.Source) {
+			return rt.handle(ctx, e)
+		}
+	}
+
+	return UnknownEventTypeProblem(e.Type, e.Source)
+}
+
+// HandleHTTP dispatches events received by an HTTPHandler, use it as HTTPHandlerConfiguration.Handle.
+func (r *Router) HandleHTTP(req *http.Request, e *CloudEvent) *problems.Problem {
+	return r.Dispatch(req.Context(), e)
+}
+
+// MsgHandler returns a handler for broker subscriptions that decodes, validates and dispatches events.
+// Problems are logged, since there is no caller to report them to.
+func (r *Router) MsgHandler() nats.MsgHandler {
+	return func(msg *nats.Msg) {
+		e, err := FromNatsMsg(msg)
+		if err != nil {
+			slog.Warn("Could not decode event", sloki.WrapError(err), slog.String("subject", msg.Subject))
+			return
+		}
+
+		problem := e.Validate()
+		if problem == nil {
+			problem = r.Dispatch(context.Background(), e)
+		}
+		if problem != nil {
+			slog.Warn(
+				"Could not handle event",
+				slog.String("problem", problem.Type),
+				slog.String("detail", problem.Detail),
+				slog.String("subject", msg.Subject),
+				slog.String("id", e.ID),
+			)
+		}
+	}
+}
+
+func matchPattern(pattern, value string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(value, prefix)
+	}
+
+	return pattern == value
+}
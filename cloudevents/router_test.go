@@ -0,0 +1,136 @@
+package cloudevents_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OliverSchlueter/goutils/broker"
+	"github.com/OliverSchlueter/goutils/cloudevents"
+	"github.com/OliverSchlueter/goutils/problems"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderCancelled struct {
+	OrderID string `json:"orderId"`
+	Reason  string `json:"reason"`
+}
+
+func newTestRouter(calls *[]string) *cloudevents.Router {
+	router := cloudevents.NewRouter()
+
+	cloudevents.RouteSource(router, "com.example.order.created", "/legacy/*", func(ctx context.Context, e *cloudevents.CloudEvent, data orderCreated) *problems.Problem {
+		*calls = append(*calls, "legacy created "+data.OrderID)
+		return nil
+	})
+	cloudevents.Route(router, "com.example.order.created", func(ctx context.Context, e *cloudevents.CloudEvent, data orderCreated) *problems.Problem {
+		*calls = append(*calls, "created "+data.OrderID)
+		return nil
+	})
+	cloudevents.Route(router, "com.example.order.cancelled", func(ctx context.Context, e *cloudevents.CloudEvent, data orderCancelled) *problems.Problem {
+		*calls = append(*calls, "cancelled "+data.OrderID+" "+data.Reason)
+		return nil
+	})
+	cloudevents.Route(router, "com.example.order.*", func(ctx context.Context, e *cloudevents.CloudEvent, data map[string]any) *problems.Problem {
+		*calls = append(*calls, "other "+e.Type)
+		return nil
+	})
+
+	return router
+}
+
+func newEvent(t *testing.T, eventType, source string, data any) *cloudevents.CloudEvent {
+	event := cloudevents.New(eventType, source)
+	require.NoError(t, event.SetData(cloudevents.ContentTypeJSON, data))
+	return event
+}
+
+func TestRouter_Dispatch(t *testing.T) {
+	var calls []string
+	router := newTestRouter(&calls)
+	ctx := context.Background()
+
+	assert.Nil(t, router.Dispatch(ctx, newEvent(t, "com.example.order.created", "/orders", orderCreated{OrderID: "o-1"})))
+	assert.Nil(t, router.Dispatch(ctx, newEvent(t, "com.example.order.created", "/legacy/orders", orderCreated{OrderID: "o-2"})))
+	assert.Nil(t, router.Dispatch(ctx, newEvent(t, "com.example.order.cancelled", "/orders", orderCancelled{OrderID: "o-3", Reason: "too expensive"})))
+	assert.Nil(t, router.Dispatch(ctx, newEvent(t, "com.example.order.shipped", "/orders", map[string]any{})))
+
+	assert.Equal(t, []string{
+		"created o-1",
+		"legacy created o-2",
+		"cancelled o-3 too expensive",
+		"other com.example.order.shipped",
+	}, calls)
+}
+
+func TestRouter_Dispatch_HandlerRegisters(t *testing.T) {
+	router := cloudevents.NewRouter()
+	var calls []string
+	cloudevents.Route(router, "com.example.order.created", func(ctx context.Context, e *cloudevents.CloudEvent, data orderCreated) *problems.Problem {
+		// registering while dispatching must not deadlock
+		router.UseSchemas(cloudevents.NewSchemaRegistry())
+		cloudevents.Route(router, "com.example.order.cancelled", func(ctx context.Context, e *cloudevents.CloudEvent, data orderCancelled) *problems.Problem {
+			calls = append(calls, "cancelled "+data.OrderID)
+			return nil
+		})
+		calls = append(calls, "created "+data.OrderID)
+		return nil
+	})
+
+	ctx := context.Background()
+	assert.Nil(t, router.Dispatch(ctx, newEvent(t, "com.example.order.created", "/orders", orderCreated{OrderID: "o-1"})))
+	assert.Nil(t, router.Dispatch(ctx, newEvent(t, "com.example.order.cancelled", "/orders", orderCancelled{OrderID: "o-1"})))
+	assert.Equal(t, []string{"created o-1", "cancelled o-1"}, calls)
+}
+
+func TestRouter_Problems(t *testing.T) {
+	var calls []string
+	router := newTestRouter(&calls)
+	ctx := context.Background()
+
+	problem := router.Dispatch(ctx, newEvent(t, "com.example.user.created", "/users", map[string]any{}))
+	require.NotNil(t, problem)
+	assert.Equal(t, "UnknownEventType", problem.Type)
+	assert.Contains(t, problem.Detail, "com.example.user.created")
+
+	problem = router.Dispatch(ctx, newEvent(t, "com.example.order.created", "/orders", "not an order"))
+	require.NotNil(t, problem)
+	assert.Equal(t, "CouldNotDecodeEventData", problem.Type)
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+
+	assert.Empty(t, calls)
+}
+
+func TestRouter_HTTP(t *testing.T) {
+	var calls []string
+	handler := cloudevents.NewHTTPHandler(cloudevents.HTTPHandlerConfiguration{
+		Handle: newTestRouter(&calls).HandleHTTP,
+	})
+
+	req, err := cloudevents.NewHTTPRequest(context.Background(), http.MethodPost, "/", newEvent(t, "com.example.order.created", "/orders", orderCreated{OrderID: "o-1"}), cloudevents.ModeBinary)
+	require.NoError(t, err)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	req, err = cloudevents.NewHTTPRequest(context.Background(), http.MethodPost, "/", newEvent(t, "com.example.user.created", "/users", map[string]any{}), cloudevents.ModeBinary)
+	require.NoError(t, err)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	assert.Equal(t, []string{"created o-1"}, calls)
+}
+
+func TestRouter_Broker(t *testing.T) {
+	var calls []string
+	b := broker.NewFakeBroker()
+	require.NoError(t, b.Subscribe("orders", newTestRouter(&calls).MsgHandler()))
+
+	require.NoError(t, cloudevents.Publish(b, "orders", newEvent(t, "com.example.order.created", "/orders", orderCreated{OrderID: "o-1"}), cloudevents.ModeStructured))
+	require.NoError(t, cloudevents.Publish(b, "orders", newEvent(t, "com.example.user.created", "/users", map[string]any{}), cloudevents.ModeStructured))
+
+	assert.Equal(t, []string{"created o-1"}, calls)
+}
@@ -0,0 +1,196 @@
+package cloudevents
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/OliverSchlueter/goutils/broker"
+	"github.com/OliverSchlueter/goutils/problems"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+// maxUpcasts limits the length of upcaster chains to protect against cycles.
+const maxUpcasts = 32
+
+type schemaKey struct {
+	eventType  string
+	dataSchema string
+}
+
+type upcaster struct {
+	toSchema string
+	upcast   func(data []byte) ([]byte, error)
+}
+
+// SchemaRegistry maps event types and their dataschema to JSON Schema definitions
+// and upcasts the data of older schema versions to the current one.
+type SchemaRegistry struct {
+	mu        sync.RWMutex
+	schemas   map[schemaKey]*jsonschema.Schema
+	upcasters map[schemaKey]upcaster
+	types     map[string]bool
+}
+
+func NewSchemaRegistry() *SchemaRegistry {
+	return &SchemaRegistry{
+		schemas:   map[schemaKey]*jsonschema.Schema{},
+		upcasters: map[schemaKey]upcaster{},
+		types:     map[string]bool{},
+	}
+}
+
+// Register adds the JSON Schema definition for the data of events with the given type and dataschema.
+// dataSchema may be empty for events without a dataschema attribute.
+func (r *SchemaRegistry) Register(eventType, dataSchema string, definition []byte) error {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(definition))
+	if err != nil {
+		return fmt.Errorf("could not parse schema of '%s': %w", eventType, err)
+	}
+
+	url := dataSchema
+	if url == "" {
+		url = "urn:cloudevents:" + eventType
+	}
+
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource(url, doc); err != nil {
+		return fmt.Errorf("could not add schema of '%s': %w", eventType, err)
+	}
+	schema, err := c.Compile(url)
+	if err != nil {
+		return fmt.Errorf("could not compile schema of '%s': %w", eventType, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.schemas[schemaKey{eventType, dataSchema}] = schema
+	r.types[eventType] = true
+	return nil
+}
+
+// RegisterUpcaster registers fn to transform the data of events with the given type from fromSchema to toSchema.
+// Upcasters are chained, so each version only needs an upcaster to its successor.
+func RegisterUpcaster[From, To any](r *SchemaRegistry, eventType, fromSchema, toSchema string, fn func(From) (To, error)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.upcasters[schemaKey{eventType, fromSchema}] = upcaster{
+		toSchema: toSchema,
+		upcast: func(data []byte) ([]byte, error) {
+			var from From
+			if err := json.Unmarshal(data, &from); err != nil {
+				return nil, err
+			}
+
+			to, err := fn(from)
+			if err != nil {
+				return nil, err
+			}
+
+			return json.Marshal(to)
+		},
+	}
+	r.types[eventType] = true
+}
+
+// Validate validates the data of e against the schema registered for its type and dataschema.
+// Events of types without any registered schema or upcaster are not validated.
+func (r *SchemaRegistry) Validate(e *CloudEvent) *problems.Problem {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.validate(e)
+}
+
+func (r *SchemaRegistry) validate(e *CloudEvent) *problems.Problem {
+	if !r.types[e.Type] {
+		return nil
+	}
+
+	key := schemaKey{e.Type, e.DataSchema}
+	schema, ok := r.schemas[key]
+	if !ok {
+		if _, ok := r.upcasters[key]; ok {
+			// old versions without schema can still be upcasted
+			return nil
+		}
+		return UnknownDataSchemaProblem(e.Type, e.DataSchema)
+	}
+
+	if !isJSON(e.DataContentType) {
+		return InvalidEventDataProblem(e.Type, fmt.Errorf("content type '%s' is not json", e.DataContentType))
+	}
+
+	data := e.Data
+	if len(data) == 0 {
+		data = []byte("null")
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return InvalidEventDataProblem(e.Type, err)
+	}
+	if err := schema.Validate(doc); err != nil {
+		return InvalidEventDataProblem(e.Type, err)
+	}
+
+	return nil
+}
+
+// Upcast returns a copy of e whose data has been transformed by all upcasters matching its type and dataschema.
+// The data of every version with a registered schema is validated against it, so upcasters can't produce invalid data;
+// the returned error wraps an InvalidEventDataProblem in that case. If no upcaster matches, e is returned as is.
+func (r *SchemaRegistry) Upcast(e *CloudEvent) (*CloudEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	upcasted := e
+	for i := 0; ; i++ {
+		u, ok := r.upcasters[schemaKey{upcasted.Type, upcasted.DataSchema}]
+		if !ok {
+			return upcasted, nil
+		}
+		if i == maxUpcasts {
+			return nil, fmt.Errorf("too many upcasts of '%s', the upcasters might contain a cycle", e.Type)
+		}
+
+		data, err := u.upcast(upcasted.Data)
+		if err != nil {
+			return nil, fmt.Errorf("could not upcast '%s' from '%s' to '%s': %w", e.Type, upcasted.DataSchema, u.toSchema, err)
+		}
+
+		next := *upcasted
+		next.DataSchema = u.toSchema
+		next.DataContentType = ContentTypeJSON
+		next.Data = data
+		upcasted = &next
+
+		if _, ok := r.schemas[schemaKey{upcasted.Type, upcasted.DataSchema}]; ok {
+			if problem := r.validate(upcasted); problem != nil {
+				return nil, fmt.Errorf("upcasted data of '%s' does not match '%s': %w", e.Type, u.toSchema, problem)
+			}
+		}
+	}
+}
+
+// DataAs upcasts the data of e to the current version and decodes it into v.
+func (r *SchemaRegistry) DataAs(e *CloudEvent, v any) error {
+	upcasted, err := r.Upcast(e)
+	if err != nil {
+		return err
+	}
+
+	return upcasted.DataAs(v)
+}
+
+// Publish validates the data of e against its schema before publishing it.
+func (r *SchemaRegistry) Publish(b broker.Broker, subject string, e *CloudEvent, mode Mode) error {
+	if problem := r.Validate(e); problem != nil {
+		return problem
+	}
+
+	return Publish(b, subject, e, mode)
+}
@@ -0,0 +1,178 @@
+package cloudevents_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/OliverSchlueter/goutils/broker"
+	"github.com/OliverSchlueter/goutils/cloudevents"
+	"github.com/OliverSchlueter/goutils/problems"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	orderSchemaV1 = "https://example.com/schemas/order-created/v1.json"
+	orderSchemaV2 = "https://example.com/schemas/order-created/v2.json"
+	orderSchemaV3 = "https://example.com/schemas/order-created/v3.json"
+)
+
+type orderCreatedV1 struct {
+	ID string `json:"id"`
+}
+
+type orderCreatedV2 struct {
+	OrderID string `json:"orderId"`
+}
+
+func newTestSchemaRegistry(t *testing.T) *cloudevents.SchemaRegistry {
+	registry := cloudevents.NewSchemaRegistry()
+
+	require.NoError(t, registry.Register("com.example.order.created", orderSchemaV1, []byte(`{
+		"type": "object",
+		"required": ["id"],
+		"properties": {"id": {"type": "string"}}
+	}`)))
+	require.NoError(t, registry.Register("com.example.order.created", orderSchemaV3, []byte(`{
+		"type": "object",
+		"required": ["orderId", "amount"],
+		"properties": {
+			"orderId": {"type": "string"},
+			"amount": {"type": "integer", "minimum": 0}
+		}
+	}`)))
+
+	cloudevents.RegisterUpcaster(registry, "com.example.order.created", orderSchemaV1, orderSchemaV2, func(v1 orderCreatedV1) (orderCreatedV2, error) {
+		return orderCreatedV2{OrderID: v1.ID}, nil
+	})
+	cloudevents.RegisterUpcaster(registry, "com.example.order.created", orderSchemaV2, orderSchemaV3, func(v2 orderCreatedV2) (orderCreated, error) {
+		return orderCreated{OrderID: v2.OrderID, Amount: 0}, nil
+	})
+
+	return registry
+}
+
+func newSchemaEvent(t *testing.T, dataSchema string, data any) *cloudevents.CloudEvent {
+	event := newEvent(t, "com.example.order.created", "/orders", data)
+	event.DataSchema = dataSchema
+	return event
+}
+
+func TestSchemaRegistry_Register_Invalid(t *testing.T) {
+	registry := cloudevents.NewSchemaRegistry()
+
+	assert.Error(t, registry.Register("t", "", []byte(`{`)))
+	assert.Error(t, registry.Register("t", "", []byte(`{"type": 42}`)))
+}
+
+func TestSchemaRegistry_Validate(t *testing.T) {
+	registry := newTestSchemaRegistry(t)
+
+	assert.Nil(t, registry.Validate(newSchemaEvent(t, orderSchemaV3, orderCreated{OrderID: "o-1", Amount: 42})))
+	assert.Nil(t, registry.Validate(newSchemaEvent(t, orderSchemaV1, orderCreatedV1{ID: "o-1"})))
+
+	// v2 has no schema, but an upcaster
+	assert.Nil(t, registry.Validate(newSchemaEvent(t, orderSchemaV2, orderCreatedV2{OrderID: "o-1"})))
+
+	// types without schemas are not validated
+	assert.Nil(t, registry.Validate(newEvent(t, "com.example.user.created", "/users", map[string]any{})))
+
+	problem := registry.Validate(newSchemaEvent(t, orderSchemaV3, map[string]any{"orderId": "o-1", "amount": -1}))
+	require.NotNil(t, problem)
+	assert.Equal(t, "InvalidEventData", problem.Type)
+	assert.Contains(t, problem.Detail, "amount")
+
+	problem = registry.Validate(newSchemaEvent(t, "https://example.com/schemas/unknown.json", map[string]any{}))
+	require.NotNil(t, problem)
+	assert.Equal(t, "UnknownDataSchema", problem.Type)
+}
+
+func TestSchemaRegistry_Upcast(t *testing.T) {
+	registry := newTestSchemaRegistry(t)
+
+	event := newSchemaEvent(t, orderSchemaV1, orderCreatedV1{ID: "o-1"})
+
+	upcasted, err := registry.Upcast(event)
+	require.NoError(t, err)
+	assert.Equal(t, orderSchemaV3, upcasted.DataSchema)
+	assert.Nil(t, registry.Validate(upcasted))
+
+	// the original event is not modified
+	assert.Equal(t, orderSchemaV1, event.DataSchema)
+
+	var data orderCreated
+	require.NoError(t, registry.DataAs(event, &data))
+	assert.Equal(t, orderCreated{OrderID: "o-1"}, data)
+
+	current := newSchemaEvent(t, orderSchemaV3, orderCreated{OrderID: "o-2", Amount: 1})
+	upcasted, err = registry.Upcast(current)
+	require.NoError(t, err)
+	assert.Same(t, current, upcasted)
+}
+
+func TestSchemaRegistry_UpcastInvalid(t *testing.T) {
+	registry := newTestSchemaRegistry(t)
+	cloudevents.RegisterUpcaster(registry, "com.example.order.created", orderSchemaV2, orderSchemaV3, func(v2 orderCreatedV2) (orderCreated, error) {
+		return orderCreated{OrderID: v2.OrderID, Amount: -1}, nil
+	})
+
+	event := newSchemaEvent(t, orderSchemaV2, orderCreatedV2{OrderID: "o-1"})
+
+	_, err := registry.Upcast(event)
+	var problem *problems.Problem
+	require.True(t, errors.As(err, &problem), "Upcasted data should be validated against the schema of the target version")
+	assert.Equal(t, "InvalidEventData", problem.Type)
+
+	router := cloudevents.NewRouter()
+	router.UseSchemas(registry)
+	problem = router.Dispatch(context.Background(), event)
+	require.NotNil(t, problem)
+	assert.Equal(t, "InvalidEventData", problem.Type)
+}
+
+func TestSchemaRegistry_UpcastCycle(t *testing.T) {
+	registry := cloudevents.NewSchemaRegistry()
+	cloudevents.RegisterUpcaster(registry, "t", "a", "b", func(v any) (any, error) { return v, nil })
+	cloudevents.RegisterUpcaster(registry, "t", "b", "a", func(v any) (any, error) { return v, nil })
+
+	event := newEvent(t, "t", "/s", map[string]any{})
+	event.DataSchema = "a"
+
+	_, err := registry.Upcast(event)
+	assert.ErrorContains(t, err, "cycle")
+}
+
+func TestSchemaRegistry_Router(t *testing.T) {
+	router := cloudevents.NewRouter()
+	router.UseSchemas(newTestSchemaRegistry(t))
+
+	var received []orderCreated
+	cloudevents.Route(router, "com.example.order.created", func(ctx context.Context, e *cloudevents.CloudEvent, data orderCreated) *problems.Problem {
+		assert.Equal(t, orderSchemaV3, e.DataSchema)
+		received = append(received, data)
+		return nil
+	})
+
+	ctx := context.Background()
+	assert.Nil(t, router.Dispatch(ctx, newSchemaEvent(t, orderSchemaV1, orderCreatedV1{ID: "o-1"})))
+	assert.Nil(t, router.Dispatch(ctx, newSchemaEvent(t, orderSchemaV3, orderCreated{OrderID: "o-2", Amount: 5})))
+
+	problem := router.Dispatch(ctx, newSchemaEvent(t, orderSchemaV1, map[string]any{"id": 1}))
+	require.NotNil(t, problem)
+	assert.Equal(t, "InvalidEventData", problem.Type)
+
+	assert.Equal(t, []orderCreated{{OrderID: "o-1"}, {OrderID: "o-2", Amount: 5}}, received)
+}
+
+func TestSchemaRegistry_Publish(t *testing.T) {
+	registry := newTestSchemaRegistry(t)
+	b := broker.NewFakeBroker()
+
+	assert.NoError(t, registry.Publish(b, "orders", newSchemaEvent(t, orderSchemaV3, orderCreated{OrderID: "o-1"}), cloudevents.ModeBinary))
+
+	err := registry.Publish(b, "orders", newSchemaEvent(t, orderSchemaV3, map[string]any{}), cloudevents.ModeBinary)
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "orderId"), err.Error())
+}
@@ -0,0 +1,97 @@
+package cloudevents
+
+import (
+	"fmt"
+	"maps"
+	"mime"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/OliverSchlueter/goutils/idgen"
+	"github.com/OliverSchlueter/goutils/problems"
+)
+
+// New creates an event of the given type and source with a generated ID and the current time.
+func New(eventType, source string) *CloudEvent {
+	return &CloudEvent{
+		SpecVersion: SpecVersion,
+		ID:          idgen.GenerateID(16),
+		Type:        eventType,
+		Source:      source,
+		Time:        time.Now().UTC(),
+	}
+}
+
+// Validate checks the event against the specification and returns a problem listing all violations,
+// or nil if the event is valid.
+func (e *CloudEvent) Validate() *problems.Problem {
+	violations := e.violations()
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return InvalidEventProblem(violations)
+}
+
+func (e *CloudEvent) violations() []string {
+	var violations []string
+
+	if e.SpecVersion == "" {
+		violations = append(violations, "'specversion' is required")
+	} else if e.SpecVersion != SpecVersion {
+		violations = append(violations, "'specversion' must be '"+SpecVersion+"'")
+	}
+	if e.ID == "" {
+		violations = append(violations, "'id' is required")
+	}
+	if e.Type == "" {
+		violations = append(violations, "'type' is required")
+	}
+
+	if e.Source == "" {
+		violations = append(violations, "'source' is required")
+	} else if !isURIReference(e.Source) {
+		violations = append(violations, "'source' must be a URI-reference")
+	}
+
+	if e.DataSchema != "" {
+		if u, err := url.Parse(e.DataSchema); err != nil || !u.IsAbs() {
+			violations = append(violations, "'dataschema' must be an absolute URI")
+		}
+	}
+
+	if e.DataContentType != "" {
+		if _, _, err := mime.ParseMediaType(e.DataContentType); err != nil {
+			violations = append(violations, "'datacontenttype' must be a valid media type")
+		}
+	}
+
+	// RFC 3339 only allows four-digit years
+	if !e.Time.IsZero() && (e.Time.Year() < 0 || e.Time.Year() > 9999) {
+		violations = append(violations, "'time' must be representable as an RFC 3339 timestamp")
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(e.Extensions)) {
+		value := e.Extensions[name]
+		if err := ValidateExtensionName(name); err != nil {
+			violations = append(violations, err.Error())
+			continue
+		}
+		if _, err := normalizeExtensionValue(value); err != nil {
+			violations = append(violations, fmt.Sprintf("invalid value of extension '%s': %s", name, err))
+		}
+	}
+
+	return violations
+}
+
+func isURIReference(s string) bool {
+	if strings.ContainsAny(s, " \t\r\n") {
+		return false
+	}
+
+	_, err := url.Parse(s)
+	return err == nil
+}
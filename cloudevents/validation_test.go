@@ -0,0 +1,76 @@
+package cloudevents_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/OliverSchlueter/goutils/cloudevents"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	event := cloudevents.New("com.example.order.created", "/orders")
+
+	assert.Equal(t, cloudevents.SpecVersion, event.SpecVersion)
+	assert.Len(t, event.ID, 16)
+	assert.Equal(t, "com.example.order.created", event.Type)
+	assert.Equal(t, "/orders", event.Source)
+	assert.WithinDuration(t, time.Now(), event.Time, time.Second)
+	assert.NotEqual(t, event.ID, cloudevents.New("t", "s").ID)
+	assert.Nil(t, event.Validate())
+}
+
+func TestCloudEvent_Validate_Required(t *testing.T) {
+	event := &cloudevents.CloudEvent{}
+
+	problem := event.Validate()
+	assert.NotNil(t, problem)
+	assert.Equal(t, "InvalidCloudEvent", problem.Type)
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Contains(t, problem.Detail, "'specversion' is required")
+	assert.Contains(t, problem.Detail, "'id' is required")
+	assert.Contains(t, problem.Detail, "'type' is required")
+	assert.Contains(t, problem.Detail, "'source' is required")
+}
+
+func TestCloudEvent_Validate_SpecVersion(t *testing.T) {
+	assert.Equal(t, "1.0", cloudevents.SpecVersion)
+
+	event := cloudevents.New("t", "/s")
+	event.SpecVersion = "1.0.2"
+
+	problem := event.Validate()
+	assert.NotNil(t, problem)
+	assert.Contains(t, problem.Detail, "'specversion' must be '1.0'")
+}
+
+func TestCloudEvent_Validate_Formats(t *testing.T) {
+	event := cloudevents.New("t", "not a uri")
+	event.DataSchema = "relative/schema.json"
+	event.DataContentType = "application/"
+	event.Time = time.Date(10000, 1, 1, 0, 0, 0, 0, time.UTC)
+	event.Extensions = map[string]any{
+		"Invalid": "v",
+		"number":  1.5,
+	}
+
+	problem := event.Validate()
+	assert.NotNil(t, problem)
+	assert.Contains(t, problem.Detail, "'source' must be a URI-reference")
+	assert.Contains(t, problem.Detail, "'dataschema' must be an absolute URI")
+	assert.Contains(t, problem.Detail, "'datacontenttype' must be a valid media type")
+	assert.Contains(t, problem.Detail, "'time' must be representable as an RFC 3339 timestamp")
+	assert.Contains(t, problem.Detail, "extension name 'Invalid'")
+	assert.Contains(t, problem.Detail, "invalid value of extension 'number'")
+}
+
+func TestCloudEvent_Validate_Valid(t *testing.T) {
+	event := cloudevents.New("com.example.order.created", "https://example.com/orders")
+	event.DataSchema = "https://example.com/schemas/order-created.json"
+	event.Subject = "o-1"
+	assert.NoError(t, event.SetData("application/cloudevents+json; charset=utf-8", map[string]string{"a": "b"}))
+	assert.NoError(t, event.SetExtension("traceparent", "00-abc-def-01"))
+
+	assert.Nil(t, event.Validate())
+}
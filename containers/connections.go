@@ -265,20 +265,9 @@ func DisconnectRedis(rc *redis.Client) {
 }
 
 // ConnectSqlite connects to a SQLite database at the given path.
+// Apart from the busy timeout of DefaultSqliteOptions, it keeps the defaults of SQLite, see ConnectSqliteWithOptions.
 func ConnectSqlite(path string) *sql.DB {
-	db, err := sql.Open("sqlite3", path)
-	if err != nil {
-		slog.Error("Failed to open sqlite database", sloki.WrapError(err))
-		os.Exit(1)
-	}
-
-	if err := db.Ping(); err != nil {
-		slog.Error("Failed to ping sqlite database", sloki.WrapError(err))
-		os.Exit(1)
-	}
-
-	slog.Info("Connected to SQLite")
-	return db
+	return ConnectSqliteWithOptions(path, SqliteOptions{BusyTimeout: DefaultSqliteOptions.BusyTimeout})
 }
 
 // DisconnectSqlite closes the connection to the SQLite database.
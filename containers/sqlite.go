@@ -0,0 +1,260 @@
+package containers
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OliverSchlueter/goutils/idgen"
+	"github.com/OliverSchlueter/goutils/sloki"
+)
+
+// SqliteSynchronous is the value of the SQLite "synchronous" pragma.
+type SqliteSynchronous string
+
+const (
+	SqliteSynchronousOff    SqliteSynchronous = "OFF"
+	SqliteSynchronousNormal SqliteSynchronous = "NORMAL"
+	SqliteSynchronousFull   SqliteSynchronous = "FULL"
+	SqliteSynchronousExtra  SqliteSynchronous = "EXTRA"
+)
+
+// SqliteOptions configures how a SQLite database is opened.
+// The pragmas are passed via the connection string, so every connection in the pool gets them.
+type SqliteOptions struct {
+	// WAL enables the write-ahead log journal mode, which allows readers to run concurrently with a writer.
+	WAL bool
+	// BusyTimeout is how long a connection waits for a lock before failing with "database is locked".
+	BusyTimeout time.Duration
+	// ForeignKeys enables foreign key constraint enforcement.
+	ForeignKeys bool
+	// Synchronous sets the synchronous level. Empty means the SQLite default (FULL).
+	Synchronous SqliteSynchronous
+	// MaxReaders is the maximum number of open connections of the reader pool (see ConnectSqlitePool).
+	MaxReaders int
+}
+
+// DefaultSqliteOptions are sensible defaults for an application database.
+// WAL and SqliteSynchronousNormal speed up concurrent access, but are opt-in: WAL creates "-wal" and "-shm" files
+// next to the database, and NORMAL may lose the last transactions on power loss.
+var DefaultSqliteOptions = SqliteOptions{
+	BusyTimeout: 5 * time.Second,
+	ForeignKeys: true,
+	MaxReaders:  4,
+}
+
+// SqlitePool holds separate connection pools for reading and writing.
+// SQLite only allows a single writer at a time, so the writer pool is limited to one connection
+// and starts its transactions with BEGIN IMMEDIATE to avoid lock upgrades failing mid-transaction.
+type SqlitePool struct {
+	Reader *sql.DB
+	Writer *sql.DB
+}
+
+// ConnectSqliteWithOptions connects to a SQLite database at the given path using the provided options.
+func ConnectSqliteWithOptions(path string, opts SqliteOptions) *sql.DB {
+	db, err := openSqlite(sqliteDSN(path, opts, nil))
+	if err != nil {
+		slog.Error("Failed to open sqlite database", sloki.WrapError(err))
+		os.Exit(1)
+	}
+
+	slog.Info("Connected to SQLite")
+	return db
+}
+
+// ConnectSqlitePool connects to a SQLite database at the given path with a read-only reader pool
+// and a single-connection writer pool. Enable SqliteOptions.WAL, so the readers are not blocked by the writer.
+func ConnectSqlitePool(path string, opts SqliteOptions) *SqlitePool {
+	writer, err := openSqlite(sqliteDSN(path, opts, url.Values{"_txlock": {"immediate"}}))
+	if err != nil {
+		slog.Error("Failed to open sqlite writer", sloki.WrapError(err))
+		os.Exit(1)
+	}
+	writer.SetMaxOpenConns(1)
+
+	reader, err := openSqlite(sqliteDSN(path, opts, url.Values{"mode": {"ro"}}))
+	if err != nil {
+		slog.Error("Failed to open sqlite reader", sloki.WrapError(err))
+		os.Exit(1)
+	}
+	if opts.MaxReaders > 0 {
+		reader.SetMaxOpenConns(opts.MaxReaders)
+	}
+
+	slog.Info("Connected to SQLite")
+	return &SqlitePool{
+		Reader: reader,
+		Writer: writer,
+	}
+}
+
+// DisconnectSqlitePool closes both pools of the SqlitePool.
+func DisconnectSqlitePool(pool *SqlitePool) {
+	if err := pool.Reader.Close(); err != nil {
+		slog.Error("Failed to close sqlite reader", sloki.WrapError(err))
+	}
+	if err := pool.Writer.Close(); err != nil {
+		slog.Error("Failed to close sqlite writer", sloki.WrapError(err))
+	}
+
+	slog.Info("Disconnected from SQLite")
+}
+
+// ConnectSqliteInMemory creates a new, empty in-memory SQLite database, intended for tests.
+// All connections of the returned pool share the same database, which is dropped once the last connection is closed.
+func ConnectSqliteInMemory() *sql.DB {
+	dsn := sqliteDSN("file:"+idgen.GenerateID(16), DefaultSqliteOptions, url.Values{
+		"mode":  {"memory"},
+		"cache": {"shared"},
+	})
+	db, err := openSqlite(dsn)
+	if err != nil {
+		slog.Error("Failed to open in-memory sqlite database", sloki.WrapError(err))
+		os.Exit(1)
+	}
+
+	// keep at least one connection open, otherwise the database is dropped
+	db.SetMaxIdleConns(1)
+	db.SetConnMaxLifetime(0)
+	db.SetConnMaxIdleTime(0)
+
+	return db
+}
+
+func openSqlite(dsn string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := db.Ping(); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return db, nil
+}
+
+func sqliteDSN(path string, opts SqliteOptions, extra url.Values) string {
+	params := url.Values{}
+	if opts.WAL {
+		params.Set("_journal_mode", "WAL")
+	}
+	if opts.BusyTimeout > 0 {
+		params.Set("_busy_timeout", strconv.FormatInt(opts.BusyTimeout.Milliseconds(), 10))
+	}
+	if opts.ForeignKeys {
+		params.Set("_foreign_keys", "1")
+	}
+	if opts.Synchronous != "" {
+		params.Set("_synchronous", string(opts.Synchronous))
+	}
+	for k, v := range extra {
+		params[k] = v
+	}
+
+	if len(params) == 0 {
+		return path
+	}
+
+	if !strings.HasPrefix(path, "file:") {
+		path = "file:" + path
+	}
+
+	// the path may already have query parameters, e.g. "file:data.db?cache=shared"
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+		if strings.HasSuffix(path, "?") || strings.HasSuffix(path, "&") {
+			sep = ""
+		}
+	}
+	return path + sep + params.Encode()
+}
+
+// MigrateSqlite applies all "*.sql" files in dir of fsys that have not been applied yet, in lexical order.
+// Applied migrations are tracked by file name in the "schema_migrations" table, and every migration
+// runs in its own transaction. fsys is typically an embed.FS.
+func MigrateSqlite(db *sql.DB, fsys fs.FS, dir string) error {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    TEXT PRIMARY KEY,
+		applied_at TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return fmt.Errorf("could not create migrations table: %w", err)
+	}
+
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return fmt.Errorf("could not read migrations: %w", err)
+	}
+
+	var versions []string
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".sql" {
+			continue
+		}
+		versions = append(versions, e.Name())
+	}
+	slices.Sort(versions)
+
+	for _, version := range versions {
+		applied, err := isMigrationApplied(db, version)
+		if err != nil {
+			return err
+		}
+		if applied {
+			continue
+		}
+
+		script, err := fs.ReadFile(fsys, path.Join(dir, version))
+		if err != nil {
+			return fmt.Errorf("could not read migration %s: %w", version, err)
+		}
+
+		if err := applyMigration(db, version, string(script)); err != nil {
+			return err
+		}
+
+		slog.Info("Applied SQLite migration", slog.String("version", version))
+	}
+
+	return nil
+}
+
+func isMigrationApplied(db *sql.DB, version string) (bool, error) {
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM schema_migrations WHERE version = ?`, version).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("could not check migration %s: %w", version, err)
+	}
+
+	return count > 0, nil
+}
+
+func applyMigration(db *sql.DB, version, script string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("could not begin migration %s: %w", version, err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(script); err != nil {
+		return fmt.Errorf("could not apply migration %s: %w", version, err)
+	}
+
+	if _, err := tx.Exec(`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`, version, time.Now()); err != nil {
+		return fmt.Errorf("could not record migration %s: %w", version, err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,127 @@
+package containers
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMigrations = fstest.MapFS{
+	"migrations/001_users.sql": {Data: []byte(`CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL);`)},
+	"migrations/002_posts.sql": {Data: []byte(`CREATE TABLE posts (
+		id      INTEGER PRIMARY KEY,
+		user_id INTEGER NOT NULL REFERENCES users(id)
+	);`)},
+	"migrations/README.md": {Data: []byte(`not a migration`)},
+}
+
+func TestSqliteDSN(t *testing.T) {
+	dsn := sqliteDSN("data.db", DefaultSqliteOptions, nil)
+
+	assert.Equal(t, "file:data.db?_busy_timeout=5000&_foreign_keys=1", dsn)
+
+	opts := DefaultSqliteOptions
+	opts.WAL = true
+	opts.Synchronous = SqliteSynchronousNormal
+	assert.Equal(t, "file:data.db?_busy_timeout=5000&_foreign_keys=1&_journal_mode=WAL&_synchronous=NORMAL", sqliteDSN("data.db", opts, nil))
+	assert.Equal(t, "data.db", sqliteDSN("data.db", SqliteOptions{}, nil))
+
+	// parameters of the path are kept
+	opts = SqliteOptions{ForeignKeys: true}
+	assert.Equal(t, "file:data.db?cache=shared&_foreign_keys=1", sqliteDSN("file:data.db?cache=shared", opts, nil))
+	assert.Equal(t, "file:data.db?_foreign_keys=1", sqliteDSN("data.db?", opts, nil))
+}
+
+func TestConnectSqlite_KeepsJournalMode(t *testing.T) {
+	db := ConnectSqlite(filepath.Join(t.TempDir(), "test.db"))
+	defer DisconnectSqlite(db)
+
+	var mode string
+	require.NoError(t, db.QueryRow(`PRAGMA journal_mode`).Scan(&mode))
+	assert.Equal(t, "delete", mode)
+}
+
+func TestConnectSqliteInMemory(t *testing.T) {
+	db := ConnectSqliteInMemory()
+	defer db.Close()
+
+	other := ConnectSqliteInMemory()
+	defer other.Close()
+
+	_, err := db.Exec(`CREATE TABLE test (id INTEGER PRIMARY KEY)`)
+	require.NoError(t, err)
+
+	// every in-memory database is isolated
+	_, err = other.Exec(`SELECT * FROM test`)
+	assert.Error(t, err)
+}
+
+func TestMigrateSqlite(t *testing.T) {
+	db := ConnectSqliteInMemory()
+	defer db.Close()
+
+	require.NoError(t, MigrateSqlite(db, testMigrations, "migrations"))
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&count))
+	assert.Equal(t, 2, count)
+
+	// running again must be a no-op
+	require.NoError(t, MigrateSqlite(db, testMigrations, "migrations"))
+
+	// foreign keys are enforced
+	_, err := db.Exec(`INSERT INTO posts (id, user_id) VALUES (1, 42)`)
+	assert.Error(t, err)
+}
+
+func TestMigrateSqlite_Failure(t *testing.T) {
+	db := ConnectSqliteInMemory()
+	defer db.Close()
+
+	fsys := fstest.MapFS{
+		"migrations/001_ok.sql":     {Data: []byte(`CREATE TABLE ok (id INTEGER);`)},
+		"migrations/002_broken.sql": {Data: []byte(`CREATE TABLE ok (id INTEGER);`)},
+	}
+
+	err := MigrateSqlite(db, fsys, "migrations")
+	assert.ErrorContains(t, err, "002_broken.sql")
+
+	var count int
+	require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM schema_migrations`).Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func TestConnectSqlitePool_ConcurrentWrites(t *testing.T) {
+	opts := DefaultSqliteOptions
+	opts.WAL = true
+	pool := ConnectSqlitePool(filepath.Join(t.TempDir(), "test.db"), opts)
+	defer DisconnectSqlitePool(pool)
+
+	require.NoError(t, MigrateSqlite(pool.Writer, testMigrations, "migrations"))
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			_, err := pool.Writer.Exec(`INSERT INTO users (id, name) VALUES (?, ?)`, id, "user")
+			assert.NoError(t, err)
+
+			var count int
+			assert.NoError(t, pool.Reader.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count))
+		}(i)
+	}
+	wg.Wait()
+
+	var count int
+	require.NoError(t, pool.Reader.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count))
+	assert.Equal(t, 50, count)
+
+	// the reader pool is read-only
+	_, err := pool.Reader.Exec(`INSERT INTO users (id, name) VALUES (100, 'nope')`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,141 @@
+package problems
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// maxResponseBytes limits the size of problem responses read by DecodeResponse.
+const maxResponseBytes = 1 << 20
+
+// UpstreamError is returned for problems received from another service that are not propagated.
+// From maps it to a BadGateway problem, while the problem of the other service is still available through errors.As.
+// For broker requests, Method is "REQUEST" and URL is the subject.
+type UpstreamError struct {
+	Method  string
+	URL     string
+	Problem *Problem
+}
+
+func (e *UpstreamError) Error() string {
+	return fmt.Sprintf("%s %s: %s", e.Method, e.URL, e.Problem.Error())
+}
+
+func (e *UpstreamError) Unwrap() error {
+	return e.Problem
+}
+
+// DecodeResponse returns the problem of resp if it is an error response with a problem body,
+// i.e. application/problem+json or application/problem+xml. The body is consumed and closed in that case.
+// It returns nil for all other responses and leaves their body untouched.
+// Only the Retry-After header is kept in the Header of the problem, since all others describe the response itself.
+func DecodeResponse(resp *http.Response) (*Problem, error) {
+	if resp.StatusCode < http.StatusBadRequest {
+		return nil, nil
+	}
+
+	switch mediaType(resp.Header.Get("Content-Type")) {
+	case "application/problem+json", "application/problem+xml":
+	default:
+		return nil, nil
+	}
+
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("could not read problem response: %w", err)
+	}
+
+	problem := Unmarshal(resp.Header.Get("Content-Type"), data)
+	if problem == nil {
+		return nil, fmt.Errorf("could not decode problem response with status %d", resp.StatusCode)
+	}
+	if problem.Status == 0 {
+		problem.Status = resp.StatusCode
+	}
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		problem.Header = http.Header{"Retry-After": {retryAfter}}
+	}
+
+	return problem, nil
+}
+
+// RetryAfter returns how long the client should wait before retrying, according to the Retry-After header of p.
+func (p *Problem) RetryAfter() (time.Duration, bool) {
+	value := p.Header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+
+	return 0, false
+}
+
+type ClientConfiguration struct {
+	// HTTPClient performs the requests. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// Propagate returns problems of other services as *Problem, so a Handler passes them on to its caller as they are.
+	// By default, they are returned as *UpstreamError and answered with BadGateway.
+	Propagate bool
+}
+
+// Client performs HTTP requests and returns problem responses as errors, see DecodeResponse.
+// It wraps a http.Client instead of being a http.RoundTripper, since a RoundTripper must not turn
+// a response it obtained into an error, e.g. for httputil.ReverseProxy.
+type Client struct {
+	http      *http.Client
+	propagate bool
+}
+
+func NewClient(cfg ClientConfiguration) *Client {
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = http.DefaultClient
+	}
+
+	return &Client{
+		http:      cfg.HTTPClient,
+		propagate: cfg.Propagate,
+	}
+}
+
+// Do sends req like http.Client.Do. If the response is a problem, it returns the problem as error instead,
+// either as *Problem or as *UpstreamError, see ClientConfiguration.Propagate.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	problem, err := DecodeResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	if problem == nil {
+		return resp, nil
+	}
+
+	if c.propagate {
+		return nil, problem
+	}
+	return nil, &UpstreamError{Method: req.Method, URL: req.URL.Redacted(), Problem: problem}
+}
+
+// Get issues a GET request to url, see Do.
+func (c *Client) Get(url string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.Do(req)
+}
@@ -0,0 +1,113 @@
+package problems_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/OliverSchlueter/goutils/problems"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newUpstream(t *testing.T) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/limited":
+			w.Header().Set("Retry-After", "30")
+			problems.TooManyRequests().WithExtension("limit", 100).WriteNegotiated(w, r)
+		case "/missing":
+			problems.NotFound("User", "12345").WriteNegotiated(w, r)
+		case "/plain":
+			http.Error(w, "plain error", http.StatusInternalServerError)
+		default:
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestClient(t *testing.T) {
+	server := newUpstream(t)
+	client := problems.NewClient(problems.ClientConfiguration{})
+
+	resp, err := client.Get(server.URL + "/ok")
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	assert.Equal(t, "ok", string(body))
+
+	// error responses without problem are returned as they are
+	resp, err = client.Get(server.URL + "/plain")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+
+	_, err = client.Get(server.URL + "/limited")
+	require.Error(t, err)
+
+	var upstream *problems.UpstreamError
+	require.ErrorAs(t, err, &upstream)
+	assert.Equal(t, http.MethodGet, upstream.Method)
+
+	var problem *problems.Problem
+	require.ErrorAs(t, err, &problem)
+	assert.Equal(t, "TooManyRequests", problem.Type)
+	assert.Equal(t, http.StatusTooManyRequests, problem.Status)
+	assert.Equal(t, float64(100), problem.Extensions["limit"])
+
+	retryAfter, ok := problem.RetryAfter()
+	require.True(t, ok)
+	assert.Equal(t, 30*time.Second, retryAfter)
+
+	// problems of other services are not passed on to our caller
+	own := problems.From(err)
+	assert.Equal(t, http.StatusBadGateway, own.Status)
+	assert.True(t, errors.Is(own, problem))
+}
+
+func TestClient_Propagate(t *testing.T) {
+	server := newUpstream(t)
+	client := problems.NewClient(problems.ClientConfiguration{Propagate: true})
+
+	_, err := client.Get(server.URL + "/missing")
+	require.Error(t, err)
+
+	own := problems.From(err)
+	assert.Equal(t, http.StatusNotFound, own.Status)
+	assert.Equal(t, "User not found", own.Title)
+}
+
+func TestDecodeResponse_XML(t *testing.T) {
+	server := newUpstream(t)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/missing", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept", problems.ContentTypeXML)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+
+	problem, err := problems.DecodeResponse(resp)
+	require.NoError(t, err)
+	require.NotNil(t, problem)
+	assert.Equal(t, "NotFound", problem.Type)
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+
+	_, ok := problem.RetryAfter()
+	assert.False(t, ok)
+}
+
+func TestProblem_RetryAfter_Date(t *testing.T) {
+	problem := problems.TooManyRequests()
+	problem.Header = http.Header{"Retry-After": {time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)}}
+
+	retryAfter, ok := problem.RetryAfter()
+	require.True(t, ok)
+	assert.InDelta(t, time.Minute.Seconds(), retryAfter.Seconds(), 2)
+}
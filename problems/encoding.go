@@ -0,0 +1,544 @@
+package problems
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"maps"
+	"mime"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	ContentTypeJSON = "application/problem+json"
+	ContentTypeXML  = "application/problem+xml"
+	ContentTypeText = "text/plain; charset=utf-8"
+
+	// xmlNamespace is the namespace of problem documents in XML, see RFC 9457 appendix B.
+	xmlNamespace = "urn:ietf:rfc:7807"
+)
+
+// offers are the media types problems can be encoded as, in order of preference, and the content type used for them.
+// Plain JSON and XML are answered with the problem media types, unless the client excluded them.
+var offers = []struct {
+	mediaType   string
+	contentType string
+}{
+	{"application/problem+json", ContentTypeJSON},
+	{"application/json", ContentTypeJSON},
+	{"application/problem+xml", ContentTypeXML},
+	{"application/xml", ContentTypeXML},
+	{"text/plain", ContentTypeText},
+	{"text/xml", ContentTypeXML},
+}
+
+// Negotiate returns the content type problems should be encoded with for the given Accept header.
+// Every supported media type gets the quality of the most specific media range matching it (RFC 9110 section 12.5.1),
+// so exclusions like "application/problem+xml;q=0" apply even if a wildcard matches too.
+// The media type with the highest quality wins, ties are broken by the specificity of the matching range.
+// If it is application/json or XML, but the problem media type was excluded, the plain media type is returned.
+// It falls back to JSON if no media type is acceptable.
+func Negotiate(accept string) string {
+	ranges := parseQualities(accept)
+
+	excluded := map[string]bool{}
+	best, bestQuality, bestSpecificity := -1, 0.0, -1
+	for i, offer := range offers {
+		quality, specificity := 0.0, -1
+		for _, r := range ranges {
+			if r.specificity > specificity && matchesMediaRange(r.value, offer.mediaType) {
+				quality, specificity = r.quality, r.specificity
+			}
+		}
+		if specificity >= 0 && quality == 0 {
+			excluded[offer.mediaType] = true
+		}
+
+		if quality > bestQuality || (quality == bestQuality && quality > 0 && specificity > bestSpecificity) {
+			best, bestQuality, bestSpecificity = i, quality, specificity
+		}
+	}
+
+	if best < 0 {
+		return ContentTypeJSON
+	}
+
+	offer := offers[best]
+	if offer.contentType != ContentTypeText && excluded[offer.contentType] {
+		return offer.mediaType
+	}
+	return offer.contentType
+}
+
+// matchesMediaRange reports whether mediaRange, e.g. "text/*", includes mediaType.
+func matchesMediaRange(mediaRange, mediaType string) bool {
+	if mediaRange == "*" || mediaRange == "*/*" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(mediaRange, "*"); ok {
+		return strings.HasPrefix(mediaType, prefix)
+	}
+	return mediaRange == mediaType
+}
+
+// qualityValue is a value of headers like Accept and Accept-Language with its quality value.
+type qualityValue struct {
+	value   string
+	quality float64
+	// specificity is 0 for "*" and "*/*", 1 for ranges like "text/*" and 2 otherwise.
+	specificity int
+}
+
+// parseQualities returns the values of headers like Accept and Accept-Language, including those with a quality of 0.
+func parseQualities(header string) []qualityValue {
+	var values []qualityValue
+	for _, part := range strings.Split(header, ",") {
+		value, params, _ := strings.Cut(part, ";")
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range strings.Split(params, ";") {
+			name, q, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if name != "q" {
+				continue
+			}
+
+			var err error
+			if quality, err = strconv.ParseFloat(q, 64); err != nil {
+				quality = 0
+			}
+		}
+
+		specificity := 2
+		if value == "*" || value == "*/*" {
+			specificity = 0
+		} else if strings.HasSuffix(value, "/*") {
+			specificity = 1
+		}
+
+		values = append(values, qualityValue{value, max(quality, 0), specificity})
+	}
+
+	return values
+}
+
+// parseQualityList returns the values of headers like Accept and Accept-Language ordered by preference,
+// i.e. by their quality value and specificity. Values with a quality of 0 are omitted.
+func parseQualityList(header string) []string {
+	candidates := slices.DeleteFunc(parseQualities(header), func(v qualityValue) bool { return v.quality <= 0 })
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].quality != candidates[j].quality {
+			return candidates[i].quality > candidates[j].quality
+		}
+		return candidates[i].specificity > candidates[j].specificity
+	})
+
+	values := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		values = append(values, c.value)
+	}
+	return values
+}
+
+// Marshal encodes p in the format of contentType, which is either JSON, XML or text.
+func Marshal(p *Problem, contentType string) ([]byte, error) {
+	switch mediaType(contentType) {
+	case "application/problem+json", "application/json":
+		return json.Marshal(p)
+	case "application/problem+xml", "application/xml", "text/xml":
+		return marshalXML(p)
+	case "text/plain":
+		return marshalText(p)
+	default:
+		return nil, fmt.Errorf("unsupported content type '%s'", contentType)
+	}
+}
+
+// Unmarshal decodes a problem encoded in the format of contentType, see Marshal.
+// Like UnmarshalJSON, it returns nil if data is empty or can't be decoded.
+func Unmarshal(contentType string, data []byte) *Problem {
+	if len(data) == 0 {
+		return nil
+	}
+
+	var (
+		problem *Problem
+		err     error
+	)
+	switch mediaType(contentType) {
+	case "application/problem+json", "application/json":
+		return UnmarshalJSON(data)
+	case "application/problem+xml", "application/xml", "text/xml":
+		problem, err = unmarshalXML(data)
+	case "text/plain":
+		problem, err = unmarshalText(data)
+	default:
+		return nil
+	}
+	if err != nil {
+		return nil
+	}
+
+	return problem
+}
+
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(contentType))
+	}
+
+	return mt
+}
+
+// extensionValues returns the extensions of p that are serialized, converted to their JSON representation,
+// i.e. maps, slices, strings, float64, bool and nil.
+func (p *Problem) extensionValues() (map[string]any, error) {
+	values := map[string]any{}
+	for name, value := range p.Extensions {
+		if members[name] {
+			continue
+		}
+
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("could not encode extension '%s': %w", name, err)
+		}
+
+		var v any
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, err
+		}
+		values[name] = v
+	}
+
+	return values, nil
+}
+
+var xmlName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9._-]*$`)
+
+// marshalXML encodes p as defined in RFC 9457 appendix B.
+// Extension members whose names are not valid XML names are omitted.
+func marshalXML(p *Problem) ([]byte, error) {
+	extensions, err := p.extensionValues()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+
+	enc := xml.NewEncoder(&buf)
+	root := xml.StartElement{Name: xml.Name{Local: "problem"}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: xmlNamespace}}}
+	if err := enc.EncodeToken(root); err != nil {
+		return nil, err
+	}
+
+	var timestamp string
+	if !p.Timestamp.IsZero() {
+		timestamp = p.Timestamp.Format(time.RFC3339Nano)
+	}
+
+	standard := []struct {
+		name  string
+		value any
+	}{
+		{"type", p.TypeURI()},
+		{"title", p.Title},
+		{"status", float64(p.Status)},
+		{"detail", p.Detail},
+		{"instance", p.Instance},
+		{"timestamp", timestamp},
+	}
+	for _, m := range standard {
+		if m.value == "" || m.value == float64(0) {
+			continue
+		}
+		if err := encodeXMLValue(enc, m.name, m.value); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(p.Errors) > 0 {
+		errs := make([]any, 0, len(p.Errors))
+		for _, fe := range p.Errors {
+			errs = append(errs, map[string]any{"pointer": fe.Pointer, "detail": fe.Detail})
+		}
+		if err := encodeXMLValue(enc, "errors", errs); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(extensions)) {
+		if !xmlName.MatchString(name) {
+			continue
+		}
+		if err := encodeXMLValue(enc, name, extensions[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := enc.EncodeToken(root.End()); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// encodeXMLValue encodes objects as child elements and arrays as "i" elements.
+func encodeXMLValue(enc *xml.Encoder, name string, value any) error {
+	start := xml.StartElement{Name: xml.Name{Local: name}}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			if !xmlName.MatchString(key) {
+				continue
+			}
+			if err := encodeXMLValue(enc, key, v[key]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if err := encodeXMLValue(enc, "i", item); err != nil {
+				return err
+			}
+		}
+	case nil:
+	case string:
+		if err := enc.EncodeToken(xml.CharData(v)); err != nil {
+			return err
+		}
+	default:
+		if err := enc.EncodeToken(xml.CharData(fmt.Sprint(v))); err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+type xmlNode struct {
+	XMLName  xml.Name
+	Text     string    `xml:",chardata"`
+	Children []xmlNode `xml:",any"`
+}
+
+// value returns the text of leaf nodes, a slice for arrays and a map for objects.
+func (n xmlNode) value() any {
+	if len(n.Children) == 0 {
+		return n.Text
+	}
+
+	isArray := true
+	for _, c := range n.Children {
+		if c.XMLName.Local != "i" {
+			isArray = false
+			break
+		}
+	}
+
+	if isArray {
+		values := make([]any, 0, len(n.Children))
+		for _, c := range n.Children {
+			values = append(values, c.value())
+		}
+		return values
+	}
+
+	values := map[string]any{}
+	for _, c := range n.Children {
+		values[c.XMLName.Local] = c.value()
+	}
+	return values
+}
+
+// unmarshalXML decodes a problem encoded by marshalXML. Extension values are decoded as strings,
+// slices and maps, since XML does not preserve the types of JSON.
+func unmarshalXML(data []byte) (*Problem, error) {
+	var root xmlNode
+	if err := xml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if root.XMLName.Local != "problem" {
+		return nil, fmt.Errorf("unexpected root element '%s'", root.XMLName.Local)
+	}
+
+	p := &Problem{}
+	for _, n := range root.Children {
+		text := strings.TrimSpace(n.Text)
+
+		switch n.XMLName.Local {
+		case "type":
+			p.Type = relativeType(text)
+		case "title":
+			p.Title = text
+		case "detail":
+			p.Detail = text
+		case "instance":
+			p.Instance = text
+		case "status":
+			status, err := strconv.Atoi(text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid status: %w", err)
+			}
+			p.Status = status
+		case "timestamp":
+			ts, err := time.Parse(time.RFC3339Nano, text)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp: %w", err)
+			}
+			p.Timestamp = ts
+		case "errors":
+			for _, item := range n.Children {
+				fe := FieldError{}
+				for _, c := range item.Children {
+					switch c.XMLName.Local {
+					case "pointer":
+						fe.Pointer = c.Text
+					case "detail":
+						fe.Detail = c.Text
+					}
+				}
+				p.Errors = append(p.Errors, fe)
+			}
+		default:
+			p.WithExtension(n.XMLName.Local, n.value())
+		}
+	}
+
+	return p, nil
+}
+
+// marshalText encodes p as "name: value" lines for humans and command line tools.
+// Errors and extensions are encoded as JSON, as are values that would not fit on a single line.
+func marshalText(p *Problem) ([]byte, error) {
+	extensions, err := p.extensionValues()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	line := func(name, value string) {
+		if value == "" {
+			return
+		}
+		if strings.ContainsAny(value, "\r\n") || strings.HasPrefix(value, `"`) || strings.TrimSpace(value) != value {
+			value = strconv.Quote(value)
+		}
+		buf.WriteString(name + ": " + value + "\n")
+	}
+
+	line("type", p.TypeURI())
+	line("title", p.Title)
+	if p.Status != 0 {
+		line("status", strconv.Itoa(p.Status))
+	}
+	line("detail", p.Detail)
+	line("instance", p.Instance)
+	if !p.Timestamp.IsZero() {
+		line("timestamp", p.Timestamp.Format(time.RFC3339Nano))
+	}
+
+	if len(p.Errors) > 0 {
+		data, err := json.Marshal(p.Errors)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("errors: " + string(data) + "\n")
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(extensions)) {
+		if strings.ContainsAny(name, ":\r\n") {
+			continue
+		}
+
+		data, err := json.Marshal(extensions[name])
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(name + ": " + string(data) + "\n")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func unmarshalText(data []byte) (*Problem, error) {
+	p := &Problem{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(scanner.Text(), ": ")
+		if !ok {
+			return nil, fmt.Errorf("invalid line '%s'", scanner.Text())
+		}
+		if name != "errors" && members[name] && strings.HasPrefix(value, `"`) {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of '%s': %w", name, err)
+			}
+			value = unquoted
+		}
+
+		switch name {
+		case "type":
+			p.Type = relativeType(value)
+		case "title":
+			p.Title = value
+		case "detail":
+			p.Detail = value
+		case "instance":
+			p.Instance = value
+		case "status":
+			status, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid status: %w", err)
+			}
+			p.Status = status
+		case "timestamp":
+			ts, err := time.Parse(time.RFC3339Nano, value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid timestamp: %w", err)
+			}
+			p.Timestamp = ts
+		case "errors":
+			if err := json.Unmarshal([]byte(value), &p.Errors); err != nil {
+				return nil, fmt.Errorf("invalid errors: %w", err)
+			}
+		default:
+			var v any
+			if err := json.Unmarshal([]byte(value), &v); err != nil {
+				return nil, fmt.Errorf("invalid value of '%s': %w", name, err)
+			}
+			p.WithExtension(name, v)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
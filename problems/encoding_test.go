@@ -0,0 +1,150 @@
+package problems_test
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OliverSchlueter/goutils/problems"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := map[string]string{
+		"":                                     problems.ContentTypeJSON,
+		"image/png":                            problems.ContentTypeJSON,
+		"*/*":                                  problems.ContentTypeJSON,
+		"application/json":                     problems.ContentTypeJSON,
+		"application/problem+xml":              problems.ContentTypeXML,
+		"text/xml":                             problems.ContentTypeXML,
+		"text/plain":                           problems.ContentTypeText,
+		"text/html, text/*;q=0.5":              problems.ContentTypeText,
+		"application/xml;q=0.9, text/plain":    problems.ContentTypeText,
+		"*/*, application/xml":                 problems.ContentTypeXML,
+		"application/json;q=0, text/plain;q=0": problems.ContentTypeJSON,
+		"application/xml;q=invalid, text/*":    problems.ContentTypeText,
+		// exclusions apply before wildcards
+		"application/problem+xml;q=0, */*":                        problems.ContentTypeJSON,
+		"application/*;q=0, */*":                                  problems.ContentTypeText,
+		"application/problem+json;q=0, application/*":             "application/json",
+		"application/json, application/problem+json;q=0":          "application/json",
+		"application/problem+xml;q=0, application/xml":            "application/xml",
+		"application/problem+xml;q=0, text/xml":                   "text/xml",
+		"application/problem+json;q=0, application/json;q=0, */*": problems.ContentTypeXML,
+		"application/json;q=0.1, application/xml;q=0.5":           problems.ContentTypeXML,
+	}
+	for accept, expected := range tests {
+		assert.Equal(t, expected, problems.Negotiate(accept), accept)
+	}
+}
+
+func newDetailedProblem() *problems.Problem {
+	problem := problems.ValidationErrors([]problems.FieldError{
+		{Pointer: "/email", Detail: "is required"},
+		{Pointer: "/items/0/name", Detail: "must have a length of at most 5"},
+	})
+	problem.Detail = "Validation failed.\nPlease check the fields."
+	problem.Timestamp = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return problem.
+		WithInstance("/orders").
+		WithExtension("requestId", "abc-123").
+		WithExtension("limits", map[string]any{"max": 5})
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+	for _, contentType := range []string{problems.ContentTypeJSON, problems.ContentTypeXML, problems.ContentTypeText} {
+		t.Run(contentType, func(t *testing.T) {
+			problem := newDetailedProblem()
+
+			data, err := problems.Marshal(problem, contentType)
+			require.NoError(t, err)
+
+			decoded := problems.Unmarshal(contentType, data)
+			require.NotNil(t, decoded, string(data))
+			assert.Equal(t, problem.Type, decoded.Type)
+			assert.Equal(t, problem.Title, decoded.Title)
+			assert.Equal(t, problem.Detail, decoded.Detail)
+			assert.Equal(t, problem.Status, decoded.Status)
+			assert.Equal(t, problem.Instance, decoded.Instance)
+			assert.True(t, problem.Timestamp.Equal(decoded.Timestamp))
+			assert.Equal(t, problem.Errors, decoded.Errors)
+			assert.Equal(t, "abc-123", decoded.Extensions["requestId"])
+			assert.Contains(t, decoded.Extensions, "limits")
+		})
+	}
+}
+
+func TestMarshal_XML(t *testing.T) {
+	data, err := problems.Marshal(newDetailedProblem(), problems.ContentTypeXML)
+	require.NoError(t, err)
+
+	var doc struct {
+		XMLName xml.Name
+		Status  int `xml:"status"`
+		Errors  []struct {
+			Pointer string `xml:"pointer"`
+		} `xml:"errors>i"`
+	}
+	require.NoError(t, xml.Unmarshal(data, &doc))
+	assert.Equal(t, "urn:ietf:rfc:7807", doc.XMLName.Space)
+	assert.Equal(t, "problem", doc.XMLName.Local)
+	assert.Equal(t, http.StatusBadRequest, doc.Status)
+	require.Len(t, doc.Errors, 2)
+	assert.Equal(t, "/items/0/name", doc.Errors[1].Pointer)
+}
+
+func TestMarshal_Text(t *testing.T) {
+	data, err := problems.Marshal(problems.NotFound("User", "12345"), problems.ContentTypeText)
+	require.NoError(t, err)
+
+	lines := strings.Split(string(data), "\n")
+	assert.Equal(t, "type: NotFound", lines[0])
+	assert.Equal(t, "title: User not found", lines[1])
+	assert.Equal(t, "status: 404", lines[2])
+	assert.Equal(t, "detail: The requested User '12345' could not be found.", lines[3])
+
+	_, err = problems.Marshal(problems.NotFound("User", "12345"), "application/yaml")
+	assert.Error(t, err)
+}
+
+func TestUnmarshal_Invalid(t *testing.T) {
+	assert.Nil(t, problems.Unmarshal(problems.ContentTypeJSON, nil))
+	assert.Nil(t, problems.Unmarshal(problems.ContentTypeXML, []byte("<problem>")))
+	assert.Nil(t, problems.Unmarshal(problems.ContentTypeXML, []byte("<other></other>")))
+	assert.Nil(t, problems.Unmarshal(problems.ContentTypeText, []byte("no separator")))
+	assert.Nil(t, problems.Unmarshal("application/yaml", []byte("type: NotFound")))
+}
+
+func TestProblem_WriteNegotiated(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/users/12345", nil)
+	req.Header.Set("Accept", "application/xml")
+
+	rr := httptest.NewRecorder()
+	problems.NotFound("User", "12345").WriteNegotiated(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, problems.ContentTypeXML, rr.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept", rr.Header().Get("Vary"))
+
+	decoded := problems.Unmarshal(rr.Header().Get("Content-Type"), rr.Body.Bytes())
+	require.NotNil(t, decoded)
+	assert.Equal(t, "NotFound", decoded.Type)
+}
+
+func TestProblem_WriteNegotiated_Excluded(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/users/12345", nil)
+	req.Header.Set("Accept", "application/json, application/problem+json;q=0")
+
+	rr := httptest.NewRecorder()
+	problems.NotFound("User", "12345").WriteNegotiated(rr, req)
+
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	decoded := problems.Unmarshal(rr.Header().Get("Content-Type"), rr.Body.Bytes())
+	require.NotNil(t, decoded)
+	assert.Equal(t, "NotFound", decoded.Type)
+}
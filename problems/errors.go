@@ -0,0 +1,86 @@
+package problems
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+)
+
+type errorMapping struct {
+	target  error
+	problem func() *Problem
+}
+
+var (
+	mappingsMu sync.RWMutex
+	mappings   = []*errorMapping{
+		{target: context.DeadlineExceeded, problem: GatewayTimeout},
+		// a canceled request is no server error, see ClientClosedRequest
+		{target: context.Canceled, problem: ClientClosedRequest},
+		{target: sql.ErrNoRows, problem: notFound},
+	}
+)
+
+// RegisterError makes From map all errors matching target (see errors.Is) to the problem created by problem.
+// Packages that can't be imported by this package register their errors with it, e.g. ratelimit.ErrRateLimitExceeded
+// or the MongoDB driver errors in mongoproblems. The returned function removes the mapping again.
+func RegisterError(target error, problem func() *Problem) (unregister func()) {
+	mappingsMu.Lock()
+	defer mappingsMu.Unlock()
+
+	m := &errorMapping{target: target, problem: problem}
+	mappings = append(mappings, m)
+
+	return func() {
+		mappingsMu.Lock()
+		defer mappingsMu.Unlock()
+
+		mappings = slices.DeleteFunc(mappings, func(other *errorMapping) bool { return other == m })
+	}
+}
+
+// From converts err into a problem.
+// Problems of other services that are not propagated (see UpstreamError) are mapped to a BadGateway problem.
+// If err is or wraps a *Problem, that problem is returned. Registered errors are mapped to their problem
+// and all other errors to an InternalServerError, which does not expose the error message.
+// The returned problem has err as cause, except for problems returned as is. It returns nil if err is nil.
+func From(err error) *Problem {
+	if err == nil {
+		return nil
+	}
+
+	var upstream *UpstreamError
+	if errors.As(err, &upstream) {
+		p := BadGateway("A service required to fulfill the request failed.").WithCause(err)
+		p.fixedDetail = true
+		return p
+	}
+
+	var problem *Problem
+	if errors.As(err, &problem) {
+		return problem
+	}
+
+	mappingsMu.RLock()
+	defer mappingsMu.RUnlock()
+
+	for _, m := range mappings {
+		if errors.Is(err, m.target) {
+			return m.problem().WithCause(err)
+		}
+	}
+
+	return InternalServerError("An unexpected error occurred.").WithCause(err)
+}
+
+// HTTPError writes err as problem to w, see From.
+func HTTPError(w http.ResponseWriter, err error) {
+	From(err).WriteToHTTP(w)
+}
+
+func notFound() *Problem {
+	return TypeNotFound.New("The requested resource could not be found.")
+}
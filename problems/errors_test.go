@@ -0,0 +1,88 @@
+package problems_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OliverSchlueter/goutils/problems"
+	"github.com/OliverSchlueter/goutils/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func findUser(id string) error {
+	if id == "" {
+		return problems.ValidationError("id", "is required")
+	}
+	return problems.NotFound("User", id).WithCause(sql.ErrNoRows)
+}
+
+func TestProblem_Error(t *testing.T) {
+	err := findUser("12345")
+	require.Error(t, err)
+	assert.Equal(t, "User not found: The requested User '12345' could not be found.", err.Error())
+	assert.ErrorIs(t, err, sql.ErrNoRows)
+
+	var problem *problems.Problem
+	require.ErrorAs(t, fmt.Errorf("could not load user: %w", err), &problem)
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+
+	// the cause is never serialized
+	data, err := json.Marshal(problem)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "no rows")
+}
+
+func TestFrom(t *testing.T) {
+	assert.Nil(t, problems.From(nil))
+
+	tests := []struct {
+		err    error
+		status int
+		typ    string
+	}{
+		{context.DeadlineExceeded, http.StatusGatewayTimeout, "GatewayTimeout"},
+		{fmt.Errorf("request: %w", context.Canceled), problems.StatusClientClosedRequest, "ClientClosedRequest"},
+		{fmt.Errorf("query failed: %w", sql.ErrNoRows), http.StatusNotFound, "NotFound"},
+		{fmt.Errorf("limited: %w", ratelimit.ErrRateLimitExceeded), http.StatusTooManyRequests, "RateLimitExceeded"},
+		{errors.New("connection refused"), http.StatusInternalServerError, "InternalServerError"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			problem := problems.From(tt.err)
+			require.NotNil(t, problem)
+			assert.Equal(t, tt.status, problem.Status)
+			assert.Equal(t, tt.typ, problem.Type)
+			assert.ErrorIs(t, problem, tt.err)
+			assert.NotContains(t, problem.Detail, tt.err.Error())
+		})
+	}
+
+	original := problems.Forbidden()
+	assert.Same(t, original, problems.From(fmt.Errorf("denied: %w", original)))
+}
+
+func TestRegisterError(t *testing.T) {
+	errLocked := errors.New("account locked")
+	unregister := problems.RegisterError(errLocked, problems.Forbidden)
+	t.Cleanup(unregister)
+
+	assert.Equal(t, http.StatusForbidden, problems.From(errLocked).Status)
+
+	unregister()
+	assert.Equal(t, http.StatusInternalServerError, problems.From(errLocked).Status)
+}
+
+func TestHTTPError(t *testing.T) {
+	rr := httptest.NewRecorder()
+	problems.HTTPError(rr, fmt.Errorf("loading user: %w", sql.ErrNoRows))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+}
@@ -0,0 +1,146 @@
+package problems
+
+import (
+	"log/slog"
+	"maps"
+	"net/http"
+	"sync"
+
+	"github.com/OliverSchlueter/goutils/idgen"
+	"github.com/OliverSchlueter/goutils/sloki"
+)
+
+// Mode controls how much of server errors is exposed to clients.
+type Mode int
+
+const (
+	// ModeProduction replaces the detail of internal server errors with a generic message and a correlation ID,
+	// and the detail of other server errors that was not written by this package with their status text.
+	// The real detail and cause are only logged.
+	ModeProduction Mode = iota
+	// ModeDevelopment exposes the detail of server errors, their cause and the stack trace
+	// of their creation as extension members.
+	ModeDevelopment
+)
+
+// CorrelationIDHeader is the header carrying the correlation ID of a request.
+// The correlation ID of incoming requests is reused for their server errors, otherwise one is generated.
+const CorrelationIDHeader = "X-Correlation-ID"
+
+// genericDetail replaces the detail of internal server errors in production mode.
+const genericDetail = "An unexpected error occurred. Please contact support and provide the correlation ID."
+
+var (
+	modeMu sync.RWMutex
+	mode   = ModeProduction
+)
+
+// SetMode sets how much of server errors is exposed to clients. Defaults to ModeProduction.
+func SetMode(m Mode) {
+	modeMu.Lock()
+	defer modeMu.Unlock()
+
+	mode = m
+}
+
+func currentMode() Mode {
+	modeMu.RLock()
+	defer modeMu.RUnlock()
+
+	return mode
+}
+
+// exposed returns p as it may be sent to clients according to the mode.
+// Server errors are logged and returned as copy with a correlation ID, which is also set in header if it is not nil.
+// r is the request the problem answers and may be nil.
+func (p *Problem) exposed(header http.Header, r *http.Request) *Problem {
+	if p.Status < http.StatusInternalServerError {
+		p.logCause()
+		return p
+	}
+
+	c := *p
+	c.Cause = nil
+	c.Extensions = maps.Clone(p.Extensions)
+
+	id, _ := p.Extensions["correlationId"].(string)
+	if id == "" && r != nil && isValidCorrelationID(r.Header.Get(CorrelationIDHeader)) {
+		id = r.Header.Get(CorrelationIDHeader)
+	}
+	if id == "" {
+		id = idgen.GenerateID(16)
+	}
+	c.WithExtension("correlationId", id)
+	if header != nil {
+		header.Set(CorrelationIDHeader, id)
+	}
+
+	attrs := []any{
+		slog.String("type", p.Type),
+		slog.Int("status", p.Status),
+		slog.String("detail", p.Detail),
+		slog.String("correlation_id", id),
+	}
+	if p.Cause != nil {
+		attrs = append(attrs, sloki.WrapError(p.Cause))
+	}
+	if r != nil {
+		attrs = append(attrs, sloki.WrapRequest(r))
+	}
+	// the other server errors describe known conditions, e.g. an unavailable dependency
+	unexpected := p.Type == TypeInternalServerError.Type
+	if unexpected {
+		slog.Error("Request failed", attrs...)
+	} else {
+		slog.Warn("Request failed", attrs...)
+	}
+
+	if currentMode() == ModeProduction {
+		switch {
+		case unexpected:
+			c.Detail = genericDetail
+		case !p.fixedDetail:
+			c.Detail = statusDetail(p.Status)
+		}
+		return &c
+	}
+
+	if p.Cause != nil {
+		c.WithExtension("cause", p.Cause.Error())
+	}
+	if p.stack != "" {
+		c.WithExtension("stackTrace", p.stack)
+	}
+	return &c
+}
+
+// statusDetail returns a generic detail for server errors with the given status.
+func statusDetail(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return genericDetail
+	}
+
+	return text + "."
+}
+
+// maxCorrelationIDLength limits the correlation IDs accepted from clients.
+const maxCorrelationIDLength = 64
+
+// isValidCorrelationID reports whether a correlation ID sent by a client is safe to be reflected in responses and logs,
+// i.e. short and only made of letters, digits, '-', '_', '.' and ':'.
+func isValidCorrelationID(id string) bool {
+	if id == "" || len(id) > maxCorrelationIDLength {
+		return false
+	}
+
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
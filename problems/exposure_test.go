@@ -0,0 +1,146 @@
+package problems_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/OliverSchlueter/goutils/broker"
+	"github.com/OliverSchlueter/goutils/problems"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const leakyDetail = "pq: relation \"users\" does not exist"
+
+// captureLogs makes the default logger write to the returned buffer until the end of the test.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	var logs bytes.Buffer
+
+	previous := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	return &logs
+}
+
+func TestExposure_Production(t *testing.T) {
+	logs := captureLogs(t)
+
+	problem := problems.InternalServerError(leakyDetail).WithCause(errors.New("query failed"))
+
+	rr := httptest.NewRecorder()
+	problem.WriteToHTTP(rr)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "does not exist")
+	assert.NotContains(t, rr.Body.String(), "query failed")
+
+	decoded := problems.UnmarshalJSON(rr.Body.Bytes())
+	require.NotNil(t, decoded)
+	correlationID, ok := decoded.Extensions["correlationId"].(string)
+	require.True(t, ok)
+	assert.Equal(t, correlationID, rr.Header().Get(problems.CorrelationIDHeader))
+	assert.NotContains(t, decoded.Extensions, "stackTrace")
+
+	assert.Contains(t, logs.String(), "does not exist")
+	assert.Contains(t, logs.String(), "query failed")
+	assert.Contains(t, logs.String(), correlationID)
+
+	// the problem itself is not modified
+	assert.Equal(t, leakyDetail, problem.Detail)
+	assert.Nil(t, problem.Extensions)
+
+	// client errors are sent as they are
+	rr = httptest.NewRecorder()
+	problems.ValidationError("email", "invalid format").WriteToHTTP(rr)
+	assert.Contains(t, rr.Body.String(), "invalid format")
+	assert.Empty(t, rr.Header().Get(problems.CorrelationIDHeader))
+}
+
+func TestExposure_Production_ExpectedServerErrors(t *testing.T) {
+	logs := captureLogs(t)
+
+	for _, problem := range []*problems.Problem{
+		problems.NotImplemented(),
+		problems.ServiceUnavailable(0),
+		problems.GatewayTimeout(),
+		problems.From(&problems.UpstreamError{Method: http.MethodGet, URL: "/users", Problem: problems.NotFound("User", "1")}),
+	} {
+		rr := httptest.NewRecorder()
+		problem.WriteToHTTP(rr)
+
+		decoded := problems.UnmarshalJSON(rr.Body.Bytes())
+		require.NotNil(t, decoded)
+		assert.Equal(t, problem.Detail, decoded.Detail, "Fixed details should be exposed")
+		assert.NotEmpty(t, decoded.Extensions["correlationId"])
+	}
+
+	// details of callers might leak internals, so they are replaced with the status text
+	rr := httptest.NewRecorder()
+	problems.BadGateway("billing at 10.0.0.7:8080 refused the connection").WriteToHTTP(rr)
+	decoded := problems.UnmarshalJSON(rr.Body.Bytes())
+	require.NotNil(t, decoded)
+	assert.Equal(t, "Bad Gateway.", decoded.Detail)
+
+	assert.NotContains(t, logs.String(), "level=ERROR", "Expected server errors should not be logged as errors")
+	assert.Contains(t, logs.String(), "level=WARN")
+}
+
+func TestExposure_InvalidCorrelationID(t *testing.T) {
+	captureLogs(t)
+
+	for _, id := range []string{"abc\r\nX-Injected: true", "<script>", strings.Repeat("a", 65)} {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set(problems.CorrelationIDHeader, id)
+
+		rr := httptest.NewRecorder()
+		problems.InternalServerError("broken").WriteNegotiated(rr, req)
+
+		generated := rr.Header().Get(problems.CorrelationIDHeader)
+		assert.NotEmpty(t, generated)
+		assert.NotEqual(t, id, generated, "Invalid correlation IDs should be replaced")
+	}
+}
+
+func TestExposure_Development(t *testing.T) {
+	problems.SetMode(problems.ModeDevelopment)
+	t.Cleanup(func() { problems.SetMode(problems.ModeProduction) })
+
+	problem := problems.InternalServerError(leakyDetail).WithCause(errors.New("query failed"))
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	req.Header.Set(problems.CorrelationIDHeader, "abc-123")
+
+	rr := httptest.NewRecorder()
+	problem.WriteNegotiated(rr, req)
+
+	decoded := problems.UnmarshalJSON(rr.Body.Bytes())
+	require.NotNil(t, decoded)
+	assert.Equal(t, leakyDetail, decoded.Detail)
+	assert.Equal(t, "abc-123", decoded.Extensions["correlationId"])
+	assert.Equal(t, "query failed", decoded.Extensions["cause"])
+	assert.Contains(t, decoded.Extensions["stackTrace"], "TestExposure_Development")
+}
+
+func TestExposure_Broker(t *testing.T) {
+	b := broker.NewFakeBroker()
+
+	var received []byte
+	require.NoError(t, b.Subscribe("errors", func(msg *nats.Msg) {
+		received = msg.Data
+	}))
+
+	problems.InternalServerError(leakyDetail).WriteToBroker(b, "errors")
+
+	var raw map[string]any
+	require.NoError(t, json.Unmarshal(received, &raw))
+	assert.NotContains(t, raw["detail"], "does not exist")
+	assert.NotEmpty(t, raw["correlationId"])
+}
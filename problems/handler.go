@@ -0,0 +1,16 @@
+package problems
+
+import (
+	"net/http"
+)
+
+// Handler adapts a handler that returns errors to an http.Handler.
+// Returned errors are converted with From and written as problem in the format negotiated with the client.
+// Server errors are logged and carry a correlation ID, so they can be found in the logs, see SetMode.
+type Handler func(w http.ResponseWriter, r *http.Request) error
+
+func (h Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h(w, r); err != nil {
+		From(err).WriteNegotiated(w, r)
+	}
+}
@@ -0,0 +1,96 @@
+package problems_test
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OliverSchlueter/goutils/middleware"
+	"github.com/OliverSchlueter/goutils/problems"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func userHandler(w http.ResponseWriter, r *http.Request) error {
+	switch r.URL.Query().Get("id") {
+	case "":
+		return problems.ValidationError("id", "is required")
+	case "missing":
+		return fmt.Errorf("could not load user: %w", sql.ErrNoRows)
+	case "broken":
+		return errors.New("connection refused by db-1.internal")
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func serve(t *testing.T, h http.Handler, target string, header http.Header) (*httptest.ResponseRecorder, *problems.Problem) {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for key, values := range header {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+
+	if rr.Code < http.StatusBadRequest {
+		return rr, nil
+	}
+
+	assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+	problem := problems.UnmarshalJSON(rr.Body.Bytes())
+	require.NotNil(t, problem)
+	return rr, problem
+}
+
+func TestHandler(t *testing.T) {
+	logs := captureLogs(t)
+
+	h := problems.Handler(userHandler)
+
+	rr, _ := serve(t, h, "/users?id=12345", nil)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+
+	rr, problem := serve(t, h, "/users", nil)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.Equal(t, "ValidationError", problem.Type)
+	assert.NotContains(t, problem.Extensions, "correlationId")
+
+	rr, problem = serve(t, h, "/users?id=missing", nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, "NotFound", problem.Type)
+	assert.Empty(t, logs.String())
+
+	rr, problem = serve(t, h, "/users?id=broken", nil)
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, "InternalServerError", problem.Type)
+	assert.NotContains(t, rr.Body.String(), "db-1.internal")
+
+	correlationID, ok := problem.Extensions["correlationId"].(string)
+	require.True(t, ok)
+	assert.Len(t, correlationID, 16)
+	assert.Equal(t, correlationID, rr.Header().Get(problems.CorrelationIDHeader))
+	assert.Contains(t, logs.String(), "Request failed")
+	assert.Contains(t, logs.String(), "db-1.internal")
+	assert.Contains(t, logs.String(), correlationID)
+
+	_, problem = serve(t, h, "/users?id=broken", http.Header{problems.CorrelationIDHeader: {"abc-123"}})
+	assert.Equal(t, "abc-123", problem.Extensions["correlationId"])
+}
+
+func TestHandler_RequestLogging(t *testing.T) {
+	logs := captureLogs(t)
+
+	h := middleware.RequestLogging(problems.Handler(userHandler))
+
+	rr, _ := serve(t, h, "/users?id=missing", nil)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Contains(t, logs.String(), "RequestLogging received")
+	assert.Contains(t, logs.String(), "status=404")
+}
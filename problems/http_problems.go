@@ -3,143 +3,334 @@ package problems
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 )
 
+// StatusClientClosedRequest is the non-standard status of requests canceled by the client, as used by nginx.
+const StatusClientClosedRequest = 499
+
+// The problem types of this package. Their constructors below set the detail and, where useful, a more specific title.
+var (
+	TypeMethodNotAllowed = MustRegister(Definition{
+		Type:        "MethodNotAllowed",
+		Title:       "Method not allowed",
+		Status:      http.StatusMethodNotAllowed,
+		Description: "The HTTP method is not supported by the resource. The Allow header lists the supported methods.",
+	})
+	TypeNotFound = MustRegister(Definition{
+		Type:        "NotFound",
+		Title:       "Not found",
+		Status:      http.StatusNotFound,
+		Description: "The requested resource does not exist or is not visible to the client.",
+	})
+	TypeGone = MustRegister(Definition{
+		Type:        "Gone",
+		Title:       "Gone",
+		Status:      http.StatusGone,
+		Description: "The requested resource existed, but has been removed permanently.",
+	})
+	TypeAlreadyExists = MustRegister(Definition{
+		Type:        "AlreadyExists",
+		Title:       "Already exists",
+		Status:      http.StatusConflict,
+		Description: "A resource with the same identifier already exists.",
+	})
+	TypeUnauthorized = MustRegister(Definition{
+		Type:        "Unauthorized",
+		Title:       "Unauthorized",
+		Status:      http.StatusUnauthorized,
+		Description: "The request lacks valid authentication credentials. The WWW-Authenticate header lists the accepted authentication schemes.",
+	})
+	TypeForbidden = MustRegister(Definition{
+		Type:        "Forbidden",
+		Title:       "Forbidden",
+		Status:      http.StatusForbidden,
+		Description: "The client is authenticated, but not allowed to access the resource.",
+	})
+	TypeRequestTimeout = MustRegister(Definition{
+		Type:        "RequestTimeout",
+		Title:       "Request Timeout",
+		Status:      http.StatusRequestTimeout,
+		Description: "The client did not send the complete request in time. It may repeat the request.",
+	})
+	TypePreconditionFailed = MustRegister(Definition{
+		Type:        "PreconditionFailed",
+		Title:       "Precondition Failed",
+		Status:      http.StatusPreconditionFailed,
+		Description: "A precondition of the request, e.g. an If-Match header, is not met, because the resource has been modified.",
+	})
+	TypeContentTooLarge = MustRegister(Definition{
+		Type:        "ContentTooLarge",
+		Title:       "Content Too Large",
+		Status:      http.StatusRequestEntityTooLarge,
+		Description: "The request body exceeds the size the resource accepts.",
+		Extensions: map[string]map[string]any{
+			"limit": {"type": "integer", "description": "The maximum size of the request body in bytes."},
+		},
+	})
+	TypeUnprocessableContent = MustRegister(Definition{
+		Type:        "UnprocessableContent",
+		Title:       "Unprocessable Content",
+		Status:      http.StatusUnprocessableEntity,
+		Description: "The request body is well-formed, but can't be processed, e.g. because it violates a business rule.",
+	})
+	TypeLocked = MustRegister(Definition{
+		Type:        "Locked",
+		Title:       "Locked",
+		Status:      http.StatusLocked,
+		Description: "The requested resource is locked, e.g. because it is being modified by another request.",
+	})
+	TypeWrongContentType = MustRegister(Definition{
+		Type:        "WrongContentType",
+		Title:       "Wrong Content-Type",
+		Status:      http.StatusUnsupportedMediaType,
+		Description: "The Content-Type of the request body is not supported by the resource.",
+	})
+	TypeWrongAcceptType = MustRegister(Definition{
+		Type:        "WrongAcceptType",
+		Title:       "Wrong Accept header",
+		Status:      http.StatusNotAcceptable,
+		Description: "The resource can't produce any of the media types listed in the Accept header.",
+	})
+	TypeCouldNotDecodeBody = MustRegister(Definition{
+		Type:        "CouldNotDecodeBody",
+		Title:       "Could not decode request body",
+		Status:      http.StatusBadRequest,
+		Description: "The request body is malformed, e.g. invalid JSON.",
+	})
+	TypeValidationError = MustRegister(Definition{
+		Type:        "ValidationError",
+		Title:       "Validation error",
+		Status:      http.StatusBadRequest,
+		Description: "One or more fields of the request are invalid. The errors member points to each invalid field.",
+	})
+	TypeTooManyRequests = MustRegister(Definition{
+		Type:        "TooManyRequests",
+		Title:       "Too Many Requests",
+		Status:      http.StatusTooManyRequests,
+		Description: "The client has sent too many requests. It should wait before sending further requests.",
+	})
+	TypeClientClosedRequest = MustRegister(Definition{
+		Type:        "ClientClosedRequest",
+		Title:       "Client Closed Request",
+		Status:      StatusClientClosedRequest,
+		Description: "The client canceled the request before the server could respond, e.g. by closing the connection.",
+	})
+	TypeInternalServerError = MustRegister(Definition{
+		Type:        "InternalServerError",
+		Title:       "Internal Server Error",
+		Status:      http.StatusInternalServerError,
+		Description: "An unexpected error occurred on the server.",
+		Extensions: map[string]map[string]any{
+			"correlationId": {"type": "string", "description": "Identifies the error in the logs of the server."},
+		},
+	})
+	TypeBadGateway = MustRegister(Definition{
+		Type:        "BadGateway",
+		Title:       "Bad Gateway",
+		Status:      http.StatusBadGateway,
+		Description: "A service required to fulfill the request failed.",
+	})
+	TypeNotImplemented = MustRegister(Definition{
+		Type:        "NotImplemented",
+		Title:       "Not Implemented",
+		Status:      http.StatusNotImplemented,
+		Description: "The functionality required to fulfill the request is not implemented yet.",
+	})
+	TypeServiceUnavailable = MustRegister(Definition{
+		Type:        "ServiceUnavailable",
+		Title:       "Service Unavailable",
+		Status:      http.StatusServiceUnavailable,
+		Description: "The server is temporarily unable to handle the request, e.g. because of maintenance. The Retry-After header tells when to retry, if known.",
+	})
+	TypeGatewayTimeout = MustRegister(Definition{
+		Type:        "GatewayTimeout",
+		Title:       "Gateway Timeout",
+		Status:      http.StatusGatewayTimeout,
+		Description: "The request could not be completed in time, e.g. because a service required to fulfill it did not respond.",
+	})
+)
+
 // MethodNotAllowed creates a Problem instance for HTTP 405 Method Not Allowed errors.
 // It takes the HTTP method that was attempted and a list of allowed methods as parameters.
 func MethodNotAllowed(method string, allowedMethods []string) *Problem {
-	return &Problem{
-		Type:      "MethodNotAllowed",
-		Title:     "Method not allowed",
-		Detail:    fmt.Sprintf("The HTTP method %s is not allowed for this resource. Allowed methods are: %v", method, allowedMethods),
-		Status:    http.StatusMethodNotAllowed,
-		Timestamp: time.Now(),
-	}
+	p := TypeMethodNotAllowed.Newf("The HTTP method %s is not allowed for this resource. Allowed methods are: %v", method, allowedMethods)
+	p.Params = map[string]any{"method": method, "allowedMethods": allowedMethods}
+	return p.WithHeader("Allow", strings.Join(allowedMethods, ", "))
 }
 
 // NotFound creates a Problem instance for HTTP 404 Not Found errors.
 // It takes the resource type (e.g., "User", "Project") and the specific resource identifier (e.g., "12345") as parameters.
 func NotFound(resourceType, resource string) *Problem {
-	return &Problem{
-		Type:      "NotFound",
-		Title:     fmt.Sprintf("%s not found", resourceType),
-		Detail:    fmt.Sprintf("The requested %s '%s' could not be found.", resourceType, resource),
-		Status:    http.StatusNotFound,
-		Timestamp: time.Now(),
-	}
+	p := TypeNotFound.Newf("The requested %s '%s' could not be found.", resourceType, resource)
+	p.Title = fmt.Sprintf("%s not found", resourceType)
+	p.Params = map[string]any{"resourceType": resourceType, "resource": resource}
+	return p
+}
+
+// Gone creates a Problem instance for HTTP 410 Gone errors.
+// Use it instead of NotFound for resources that have been deleted permanently.
+func Gone(resourceType, resource string) *Problem {
+	p := TypeGone.Newf("The requested %s '%s' has been removed.", resourceType, resource)
+	p.Title = fmt.Sprintf("%s gone", resourceType)
+	p.Params = map[string]any{"resourceType": resourceType, "resource": resource}
+	return p
 }
 
 func AlreadyExists(resourceType, resource string) *Problem {
-	return &Problem{
-		Type:      "AlreadyExists",
-		Title:     fmt.Sprintf("%s already exists", resourceType),
-		Detail:    fmt.Sprintf("The requested %s '%s' already exists.", resourceType, resource),
-		Status:    http.StatusConflict,
-		Timestamp: time.Now(),
-	}
+	p := TypeAlreadyExists.Newf("The requested %s '%s' already exists.", resourceType, resource)
+	p.Title = fmt.Sprintf("%s already exists", resourceType)
+	p.Params = map[string]any{"resourceType": resourceType, "resource": resource}
+	return p
 }
 
+// DefaultChallenge is the challenge of the WWW-Authenticate header of Unauthorized problems, if no challenge is given.
+const DefaultChallenge = "Bearer"
+
 // Unauthorized creates a Problem instance for HTTP 401 Unauthorized errors.
 // It indicates that the request requires user authentication.
-func Unauthorized() *Problem {
-	return &Problem{
-		Type:      "Unauthorized",
-		Title:     "Unauthorized",
-		Detail:    "Authentication is required to access this resource.",
-		Status:    http.StatusUnauthorized,
-		Timestamp: time.Now(),
+// Each challenge (e.g. `Basic realm="api"`) is sent in a WWW-Authenticate header, which defaults to DefaultChallenge.
+func Unauthorized(challenges ...string) *Problem {
+	if len(challenges) == 0 {
+		challenges = []string{DefaultChallenge}
 	}
+
+	p := TypeUnauthorized.New("Authentication is required to access this resource.")
+	for _, challenge := range challenges {
+		p.addHeader("WWW-Authenticate", challenge)
+	}
+	return p
 }
 
 // Forbidden creates a Problem instance for HTTP 403 Forbidden errors.
 // It indicates that the server understood the request, but refuses to authorize it.
 func Forbidden() *Problem {
-	return &Problem{
-		Type:      "Forbidden",
-		Title:     "Forbidden",
-		Detail:    "You do not have permission to access this resource.",
-		Status:    http.StatusForbidden,
-		Timestamp: time.Now(),
-	}
+	return TypeForbidden.New("You do not have permission to access this resource.")
+}
+
+// RequestTimeout creates a Problem instance for HTTP 408 Request Timeout errors.
+// It indicates that the client did not send the complete request in time.
+func RequestTimeout() *Problem {
+	return TypeRequestTimeout.New("The request was not received completely in time.").WithHeader("Connection", "close")
+}
+
+// PreconditionFailed creates a Problem instance for HTTP 412 Precondition Failed errors.
+// It takes the header holding the precondition, e.g. "If-Match".
+func PreconditionFailed(header string) *Problem {
+	p := TypePreconditionFailed.Newf("The precondition given in the %s header is not met.", header)
+	p.Params = map[string]any{"header": header}
+	return p
+}
+
+// ContentTooLarge creates a Problem instance for HTTP 413 Content Too Large errors.
+// It takes the maximum size of the request body in bytes, which is also sent as the "limit" extension.
+func ContentTooLarge(limit int64) *Problem {
+	p := TypeContentTooLarge.Newf("The request body exceeds the limit of %d bytes.", limit)
+	p.Params = map[string]any{"limit": limit}
+	return p.WithExtension("limit", limit)
+}
+
+// UnprocessableContent creates a Problem instance for HTTP 422 Unprocessable Content errors.
+// Use it for well-formed requests that violate business rules, and ValidationError for invalid fields.
+func UnprocessableContent(detail string) *Problem {
+	return TypeUnprocessableContent.New(detail)
+}
+
+// Locked creates a Problem instance for HTTP 423 Locked errors.
+// It takes the resource type and the identifier of the locked resource.
+func Locked(resourceType, resource string) *Problem {
+	p := TypeLocked.Newf("The %s '%s' is locked.", resourceType, resource)
+	p.Title = fmt.Sprintf("%s locked", resourceType)
+	p.Params = map[string]any{"resourceType": resourceType, "resource": resource}
+	return p
 }
 
 // WrongContentType creates a Problem instance for HTTP 415 Unsupported Media Type errors.
 // It indicates that the request's Content-Type header does not match the expected type.
 func WrongContentType(expected, actual string) *Problem {
-	return &Problem{
-		Type:      "WrongContentType",
-		Title:     "Wrong Content-Type",
-		Detail:    fmt.Sprintf("Expected Content-Type '%s', but got '%s'.", expected, actual),
-		Status:    http.StatusUnsupportedMediaType,
-		Timestamp: time.Now(),
-	}
+	p := TypeWrongContentType.Newf("Expected Content-Type '%s', but got '%s'.", expected, actual)
+	p.Params = map[string]any{"expected": expected, "actual": actual}
+	return p
 }
 
 // WrongAcceptType creates a Problem instance for HTTP 406 Not Acceptable errors.
 // It indicates that the request's Accept header does not match the expected type.
 func WrongAcceptType(expected, actual string) *Problem {
-	return &Problem{
-		Type:      "WrongAcceptType",
-		Title:     "Wrong Accept header",
-		Detail:    fmt.Sprintf("Expected Accept header '%s', but got '%s'.", expected, actual),
-		Status:    http.StatusNotAcceptable,
-		Timestamp: time.Now(),
-	}
+	p := TypeWrongAcceptType.Newf("Expected Accept header '%s', but got '%s'.", expected, actual)
+	p.Params = map[string]any{"expected": expected, "actual": actual}
+	return p
 }
 
 // CouldNotDecodeBody creates a Problem instance for HTTP 400 Bad Request errors.
 // It indicates that the request body could not be decoded, which is typically due to an invalid format.
 func CouldNotDecodeBody() *Problem {
-	return &Problem{
-		Type:      "CouldNotDecodeBody",
-		Title:     "Could not decode request body",
-		Detail:    "The request body could not be decoded. Please check the request format.",
-		Status:    http.StatusBadRequest,
-		Timestamp: time.Now(),
-	}
+	return TypeCouldNotDecodeBody.New("The request body could not be decoded. Please check the request format.")
 }
 
+// ValidationError creates a Problem instance for HTTP 400 Bad Request errors caused by a single invalid field.
+// field is either a member name like "email" or a JSON Pointer like "/items/0/name".
+// Use Validation or ValidationErrors to report multiple fields at once.
 func ValidationError(field, reason string) *Problem {
-	return &Problem{
-		Type:      "ValidationError",
-		Title:     "Validation error",
-		Detail:    fmt.Sprintf("Validation failed for field '%s': %s", field, reason),
-		Status:    http.StatusBadRequest,
-		Timestamp: time.Now(),
+	pointer := field
+	if !strings.HasPrefix(field, "/") {
+		pointer = Pointer(field)
 	}
+
+	p := TypeValidationError.Newf("Validation failed for field '%s': %s", field, reason)
+	p.Params = map[string]any{"field": field, "reason": reason}
+	p.Errors = []FieldError{{Pointer: pointer, Detail: reason}}
+	return p
 }
 
+// TooManyRequests creates a Problem instance for HTTP 429 Too Many Requests errors.
+// Use WithRetryAfter to tell the client when to retry.
 func TooManyRequests() *Problem {
-	return &Problem{
-		Type:      "TooManyRequests",
-		Title:     "Too Many Requests",
-		Detail:    "You have sent too many requests in a given amount of time. Please try again later.",
-		Status:    http.StatusTooManyRequests,
-		Timestamp: time.Now(),
-	}
+	return TypeTooManyRequests.New("You have sent too many requests in a given amount of time. Please try again later.")
+}
+
+// ClientClosedRequest creates a Problem instance for the non-standard HTTP 499 Client Closed Request errors.
+// From maps context.Canceled to it. It is rarely seen by the client, which has usually gone already.
+func ClientClosedRequest() *Problem {
+	return TypeClientClosedRequest.New("The request was canceled by the client.")
 }
 
 // InternalServerError creates a Problem instance for HTTP 500 Internal Server Error.
 // It takes a detail message that provides more context about the error.
+// The detail is only sent to clients in development mode, see SetMode.
 func InternalServerError(detail string) *Problem {
-	return &Problem{
-		Type:      "InternalServerError",
-		Title:     "Internal Server Error",
-		Detail:    detail,
-		Status:    http.StatusInternalServerError,
-		Timestamp: time.Now(),
-	}
+	return TypeInternalServerError.New(detail)
 }
 
 // NotImplemented creates a Problem instance for HTTP 501 Not Implemented errors.
 // It indicates that the server does not support the functionality required to fulfill the request.
 func NotImplemented() *Problem {
-	return &Problem{
-		Type:      "NotImplemented",
-		Title:     "Not Implemented",
-		Detail:    "This feature is not implemented yet.",
-		Status:    http.StatusNotImplemented,
-		Timestamp: time.Now(),
+	p := TypeNotImplemented.New("This feature is not implemented yet.")
+	p.fixedDetail = true
+	return p
+}
+
+// BadGateway creates a Problem instance for HTTP 502 Bad Gateway errors.
+// It indicates that a service required to fulfill the request responded with an error.
+func BadGateway(detail string) *Problem {
+	return TypeBadGateway.New(detail)
+}
+
+// ServiceUnavailable creates a Problem instance for HTTP 503 Service Unavailable errors.
+// If retryAfter is positive, it is sent in the Retry-After header.
+func ServiceUnavailable(retryAfter time.Duration) *Problem {
+	p := TypeServiceUnavailable.New("The service is temporarily unavailable. Please try again later.")
+	p.fixedDetail = true
+	if retryAfter > 0 {
+		p.WithRetryAfter(retryAfter)
 	}
+	return p
+}
+
+// GatewayTimeout creates a Problem instance for HTTP 504 Gateway Timeout errors.
+// It indicates that the request could not be completed in time, e.g. because its context expired.
+func GatewayTimeout() *Problem {
+	p := TypeGatewayTimeout.New("The request could not be completed in time.")
+	p.fixedDetail = true
+	return p
 }
@@ -0,0 +1,199 @@
+package problems
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// Message holds the templates of the title and detail of a problem type in one locale.
+// The templates are text/template templates executed with the Params and Extensions of the problem,
+// e.g. "{{.resourceType}} nicht gefunden". An empty template keeps the original text.
+type Message struct {
+	Title  string
+	Detail string
+}
+
+type localizedMessage struct {
+	title  *template.Template
+	detail *template.Template
+}
+
+type CatalogConfiguration struct {
+	// DefaultLocale is the last locale of every fallback chain. Defaults to "en".
+	DefaultLocale string
+	// Fallbacks are the locales tried after a locale before truncating it, e.g. {"de-ch": {"de-de"}}.
+	// Without fallbacks, "de-CH" falls back to "de" and then to the default locale.
+	Fallbacks map[string][]string
+}
+
+// Catalog holds localized messages keyed by problem type and locale.
+// Localizing only changes the title and detail, the type and status of problems stay the same in all languages.
+type Catalog struct {
+	mu            sync.RWMutex
+	defaultLocale string
+	fallbacks     map[string][]string
+	messages      map[string]map[string]localizedMessage
+	// names are the locales as they were added, keyed by their normalized form
+	names map[string]string
+}
+
+func NewCatalog(cfg CatalogConfiguration) *Catalog {
+	if cfg.DefaultLocale == "" {
+		cfg.DefaultLocale = "en"
+	}
+
+	fallbacks := map[string][]string{}
+	for locale, chain := range cfg.Fallbacks {
+		for _, fallback := range chain {
+			fallbacks[normalizeLocale(locale)] = append(fallbacks[normalizeLocale(locale)], normalizeLocale(fallback))
+		}
+	}
+
+	return &Catalog{
+		defaultLocale: normalizeLocale(cfg.DefaultLocale),
+		fallbacks:     fallbacks,
+		messages:      map[string]map[string]localizedMessage{},
+		names:         map[string]string{},
+	}
+}
+
+// Add adds the message of problemType in locale, replacing any previous message.
+func (c *Catalog) Add(locale, problemType string, msg Message) error {
+	var (
+		lm  localizedMessage
+		err error
+	)
+	if msg.Title != "" {
+		if lm.title, err = template.New("title").Option("missingkey=error").Parse(msg.Title); err != nil {
+			return fmt.Errorf("invalid title of '%s' in '%s': %w", problemType, locale, err)
+		}
+	}
+	if msg.Detail != "" {
+		if lm.detail, err = template.New("detail").Option("missingkey=error").Parse(msg.Detail); err != nil {
+			return fmt.Errorf("invalid detail of '%s' in '%s': %w", problemType, locale, err)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	normalized := normalizeLocale(locale)
+	if c.messages[normalized] == nil {
+		c.messages[normalized] = map[string]localizedMessage{}
+		c.names[normalized] = locale
+	}
+	c.messages[normalized][problemType] = lm
+	return nil
+}
+
+// Locales returns the fallback chain of the given locales in order of preference, ending with the default locale.
+func (c *Catalog) Locales(locales ...string) []string {
+	var chain []string
+	seen := map[string]bool{}
+	add := func(locale string) {
+		if !seen[locale] {
+			seen[locale] = true
+			chain = append(chain, locale)
+		}
+	}
+
+	for _, locale := range locales {
+		locale = normalizeLocale(locale)
+		if locale == "*" {
+			continue
+		}
+
+		add(locale)
+		for _, fallback := range c.fallbacks[locale] {
+			add(fallback)
+		}
+		for i := strings.LastIndex(locale, "-"); i > 0; i = strings.LastIndex(locale, "-") {
+			locale = locale[:i]
+			add(locale)
+		}
+	}
+	add(c.defaultLocale)
+
+	return chain
+}
+
+// Localize returns a copy of p with its title and detail in the first locale of the fallback chain
+// that has a message for the type of p, and that locale. If no locale has one, p and an empty locale are returned.
+func (c *Catalog) Localize(p *Problem, locales ...string) (*Problem, string) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, locale := range c.Locales(locales...) {
+		lm, ok := c.messages[locale][p.Type]
+		if !ok {
+			continue
+		}
+
+		data := maps.Clone(p.Extensions)
+		if data == nil {
+			data = map[string]any{}
+		}
+		maps.Copy(data, p.Params)
+
+		title, err := execute(lm.title, data, p.Title)
+		if err != nil {
+			continue
+		}
+		detail, err := execute(lm.detail, data, p.Detail)
+		if err != nil {
+			continue
+		}
+
+		localized := *p
+		localized.Title = title
+		localized.Detail = detail
+		return &localized, c.names[locale]
+	}
+
+	return p, ""
+}
+
+// LocalizeRequest localizes p for the Accept-Language header of r, see Localize.
+func (c *Catalog) LocalizeRequest(p *Problem, r *http.Request) (*Problem, string) {
+	return c.Localize(p, parseQualityList(r.Header.Get("Accept-Language"))...)
+}
+
+func execute(t *template.Template, data map[string]any, original string) (string, error) {
+	if t == nil {
+		return original, nil
+	}
+
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
+
+var (
+	catalogMu sync.RWMutex
+	catalog   *Catalog
+)
+
+// SetCatalog sets the catalog WriteNegotiated localizes problems with. A nil catalog disables localization.
+func SetCatalog(c *Catalog) {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+
+	catalog = c
+}
+
+func currentCatalog() *Catalog {
+	catalogMu.RLock()
+	defer catalogMu.RUnlock()
+
+	return catalog
+}
@@ -0,0 +1,100 @@
+package problems_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OliverSchlueter/goutils/problems"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestCatalog(t *testing.T) *problems.Catalog {
+	catalog := problems.NewCatalog(problems.CatalogConfiguration{
+		DefaultLocale: "en",
+		Fallbacks:     map[string][]string{"de-AT": {"de-DE"}},
+	})
+
+	require.NoError(t, catalog.Add("de", "NotFound", problems.Message{
+		Title:  "{{.resourceType}} nicht gefunden",
+		Detail: "{{.resourceType}} '{{.resource}}' konnte nicht gefunden werden.",
+	}))
+	require.NoError(t, catalog.Add("de-DE", "Forbidden", problems.Message{
+		Title: "Verboten",
+	}))
+	require.NoError(t, catalog.Add("fr", "NotFound", problems.Message{
+		Title: "{{.resourceType}} introuvable",
+	}))
+	require.NoError(t, catalog.Add("en", "ValidationError", problems.Message{
+		Detail: "{{.count}} fields are invalid.",
+	}))
+
+	return catalog
+}
+
+func TestCatalog_Add_Invalid(t *testing.T) {
+	catalog := problems.NewCatalog(problems.CatalogConfiguration{})
+	assert.Error(t, catalog.Add("de", "NotFound", problems.Message{Title: "{{.resourceType"}))
+}
+
+func TestCatalog_Locales(t *testing.T) {
+	catalog := newTestCatalog(t)
+
+	assert.Equal(t, []string{"de-at", "de-de", "de", "fr", "en"}, catalog.Locales("de-AT", "fr"))
+	assert.Equal(t, []string{"zh-hant-tw", "zh-hant", "zh", "en"}, catalog.Locales("zh_Hant_TW", "*"))
+	assert.Equal(t, []string{"en"}, catalog.Locales())
+}
+
+func TestCatalog_Localize(t *testing.T) {
+	catalog := newTestCatalog(t)
+	problem := problems.NotFound("User", "12345")
+
+	localized, locale := catalog.Localize(problem, "de-CH")
+	assert.Equal(t, "de", locale)
+	assert.Equal(t, "User nicht gefunden", localized.Title)
+	assert.Equal(t, "User '12345' konnte nicht gefunden werden.", localized.Detail)
+	assert.Equal(t, problem.Type, localized.Type)
+	assert.Equal(t, problem.Status, localized.Status)
+
+	// the original problem is not modified
+	assert.Equal(t, "User not found", problem.Title)
+
+	// missing templates keep the original text
+	localized, locale = catalog.Localize(problem, "fr")
+	assert.Equal(t, "fr", locale)
+	assert.Equal(t, "User introuvable", localized.Title)
+	assert.Equal(t, problem.Detail, localized.Detail)
+
+	localized, locale = catalog.Localize(problems.Forbidden(), "de-AT")
+	assert.Equal(t, "de-DE", locale)
+	assert.Equal(t, "Verboten", localized.Title)
+
+	localized, locale = catalog.Localize(problems.Forbidden(), "fr")
+	assert.Empty(t, locale)
+	assert.Equal(t, "Forbidden", localized.Title)
+
+	localized, locale = catalog.Localize(problems.NewValidation().Add("/a", "x").Add("/b", "y").Problem(), "ja")
+	assert.Equal(t, "en", locale)
+	assert.Equal(t, "2 fields are invalid.", localized.Detail)
+}
+
+func TestProblem_WriteNegotiated_Localized(t *testing.T) {
+	problems.SetCatalog(newTestCatalog(t))
+	t.Cleanup(func() { problems.SetCatalog(nil) })
+
+	req := httptest.NewRequest(http.MethodGet, "/users/12345", nil)
+	req.Header.Set("Accept-Language", "it;q=0.9, fr-CA, de;q=0.5")
+
+	rr := httptest.NewRecorder()
+	problems.NotFound("User", "12345").WriteNegotiated(rr, req)
+
+	assert.Equal(t, "fr", rr.Header().Get("Content-Language"))
+	assert.Equal(t, []string{"Accept", "Accept-Language"}, rr.Header().Values("Vary"))
+
+	decoded := problems.UnmarshalJSON(rr.Body.Bytes())
+	require.NotNil(t, decoded)
+	assert.Equal(t, "User introuvable", decoded.Title)
+	assert.Equal(t, "NotFound", decoded.Type)
+	assert.Equal(t, http.StatusNotFound, decoded.Status)
+}
@@ -4,43 +4,190 @@ import (
 	"encoding/json"
 	"github.com/OliverSchlueter/goutils/broker"
 	"github.com/OliverSchlueter/goutils/sloki"
+	"github.com/nats-io/nats.go"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// Problem represents a structured error response in compliance with RFC 7807.
+// Problem represents a structured error response in compliance with RFC 9457, which obsoletes RFC 7807.
 type Problem struct {
+	// Type identifies the problem type. Relative types like "NotFound" are resolved against the base URI when serialized, see SetBaseURI.
 	Type      string    `json:"type"`
 	Title     string    `json:"title"`
 	Detail    string    `json:"detail"`
 	Status    int       `json:"status"`
 	Timestamp time.Time `json:"timestamp"`
+	// Instance identifies the specific occurrence of the problem, e.g. the path of the request.
+	Instance string `json:"instance,omitempty"`
+	// Errors lists the individual failures of a request, e.g. all invalid fields of a form.
+	Errors []FieldError `json:"errors,omitempty"`
+	// Extensions are additional members, which are serialized as top-level members of the problem.
+	Extensions map[string]any `json:"-"`
+	// Params are the values the title and detail have been formatted with, so they can be localized, see Catalog.
+	Params map[string]any `json:"-"`
+	// Header holds HTTP headers that belong to the problem, e.g. the Allow header of MethodNotAllowed
+	// or the Retry-After header of a problem received from another service. WriteToHTTP and WriteNegotiated send them.
+	Header http.Header `json:"-"`
+	// Cause is the error that caused the problem. It is logged when the problem is written.
+	// Only in development mode, server errors also expose it in the "cause" extension, see SetMode.
+	Cause error `json:"-"`
+
+	// stack is the stack trace of the creation of server errors in development mode.
+	stack string
+	// fixedDetail marks server errors whose detail is a fixed text of this package, which is safe to expose in production mode.
+	fixedDetail bool
+}
+
+// Error implements the error interface, so problems can be returned as errors.
+func (p *Problem) Error() string {
+	if p.Title == "" {
+		return p.Detail
+	}
+	if p.Detail == "" {
+		return p.Title
+	}
+
+	return p.Title + ": " + p.Detail
+}
+
+// Unwrap returns the cause of p.
+func (p *Problem) Unwrap() error {
+	return p.Cause
+}
+
+// WithCause sets the cause of p and returns p.
+func (p *Problem) WithCause(err error) *Problem {
+	p.Cause = err
+	return p
+}
+
+// WithInstance sets the instance of p and returns p.
+func (p *Problem) WithInstance(instance string) *Problem {
+	p.Instance = instance
+	return p
+}
+
+// WithExtension sets the extension member name of p and returns p.
+// Extensions named like the standard members are ignored when serializing.
+func (p *Problem) WithExtension(name string, value any) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = map[string]any{}
+	}
+	p.Extensions[name] = value
+	return p
+}
+
+// WithHeader sets the HTTP header key of p to value and returns p.
+func (p *Problem) WithHeader(key, value string) *Problem {
+	if p.Header == nil {
+		p.Header = http.Header{}
+	}
+	p.Header.Set(key, value)
+	return p
+}
+
+// WithRetryAfter sets the Retry-After header of p to d, rounded up to whole seconds, and returns p.
+func (p *Problem) WithRetryAfter(d time.Duration) *Problem {
+	return p.WithHeader("Retry-After", strconv.Itoa(int(math.Ceil(max(d, 0).Seconds()))))
+}
+
+func (p *Problem) addHeader(key, value string) {
+	if p.Header == nil {
+		p.Header = http.Header{}
+	}
+	p.Header.Add(key, value)
 }
 
+// WriteToHTTP writes p as JSON to w, see WriteNegotiated for other formats.
 func (p *Problem) WriteToHTTP(w http.ResponseWriter) {
-	w.Header().Set("Content-Type", "application/problem+json")
-	w.WriteHeader(p.Status)
+	p.exposed(w.Header(), nil).write(w, ContentTypeJSON)
+}
 
-	data, err := json.Marshal(p)
+// WriteNegotiated writes p to w in the format the client prefers according to the Accept header of r.
+// If a catalog is set (see SetCatalog), p is localized according to the Accept-Language header of r.
+func (p *Problem) WriteNegotiated(w http.ResponseWriter, r *http.Request) {
+	w.Header().Add("Vary", "Accept")
+
+	exposed := p.exposed(w.Header(), r)
+	if c := currentCatalog(); c != nil {
+		w.Header().Add("Vary", "Accept-Language")
+
+		localized, locale := c.LocalizeRequest(exposed, r)
+		if locale != "" {
+			w.Header().Set("Content-Language", locale)
+		}
+		exposed = localized
+	}
+
+	exposed.write(w, Negotiate(r.Header.Get("Accept")))
+}
+
+func (p *Problem) write(w http.ResponseWriter, contentType string) {
+	data, err := Marshal(p, contentType)
 	if err != nil {
 		slog.Warn("failed to marshal problem response", sloki.WrapError(err))
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 
+	for key, values := range p.Header {
+		w.Header().Del(key)
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(p.Status)
 	_, _ = w.Write(data)
 }
 
+var headerValueReplacer = strings.NewReplacer("\r", " ", "\n", " ")
+
+// WriteToBroker publishes p as JSON to subj. Its status and title are set in the Nats-Service-Error headers,
+// so requesters can tell it from a successful reply, see broker.Request and RespondToBroker.
+// If b is no broker.MsgPublisher, only the JSON is published.
 func (p *Problem) WriteToBroker(b broker.Broker, subj string) {
-	data, err := json.Marshal(p)
+	exposed := p.exposed(nil, nil)
+	data, err := json.Marshal(exposed)
 	if err != nil {
 		slog.Warn("failed to marshal problem response", sloki.WrapError(err))
 		return
 	}
 
-	if err := b.Publish(subj, data); err != nil {
+	msg := nats.NewMsg(subj)
+	for key, values := range exposed.Header {
+		msg.Header[key] = values
+	}
+	if id, ok := exposed.Extensions["correlationId"].(string); ok {
+		msg.Header.Set(CorrelationIDHeader, id)
+	}
+	msg.Header.Set("Content-Type", ContentTypeJSON)
+	// line breaks would end the header and let the title inject others
+	msg.Header.Set(broker.ServiceErrorHeader, headerValueReplacer.Replace(exposed.Title))
+	msg.Header.Set(broker.ServiceErrorCodeHeader, strconv.Itoa(exposed.Status))
+	msg.Data = data
+
+	// brokers without headers still get the problem, only without the Nats-Service-Error headers
+	if publisher, ok := b.(broker.MsgPublisher); ok {
+		err = publisher.PublishMsg(msg)
+	} else {
+		err = b.Publish(subj, data)
+	}
+	if err != nil {
 		slog.Error("failed to publish problem response", sloki.WrapError(err), "subject", subj)
 		return
 	}
 }
+
+func (p *Problem) logCause() {
+	if p.Cause == nil {
+		return
+	}
+
+	// client errors are expected, so their causes are only relevant for debugging
+	slog.Debug("problem caused by error", sloki.WrapError(p.Cause), slog.String("type", p.Type), slog.Int("status", p.Status))
+}
@@ -0,0 +1,18 @@
+// Package mongoproblems maps the errors of the MongoDB driver to problems.
+// It only has to be imported for its side effect:
+//
+//	import _ "github.com/OliverSchlueter/goutils/problems/mongoproblems"
+package mongoproblems
+
+import (
+	"github.com/OliverSchlueter/goutils/problems"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func init() {
+	problems.RegisterError(mongo.ErrNoDocuments, notFound)
+}
+
+func notFound() *problems.Problem {
+	return problems.TypeNotFound.New("The requested resource could not be found.")
+}
@@ -0,0 +1,20 @@
+package mongoproblems_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/OliverSchlueter/goutils/problems"
+	_ "github.com/OliverSchlueter/goutils/problems/mongoproblems"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+func TestFrom_NoDocuments(t *testing.T) {
+	problem := problems.From(fmt.Errorf("loading user: %w", mongo.ErrNoDocuments))
+
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+	assert.Equal(t, "NotFound", problem.Type)
+	assert.ErrorIs(t, problem, mongo.ErrNoDocuments)
+}
@@ -1,9 +1,88 @@
 package problems
 
 import (
+	"bytes"
 	"encoding/json"
 )
 
+// members are the names of the members that are serialized from the fields of Problem.
+var members = map[string]bool{
+	"type":      true,
+	"title":     true,
+	"detail":    true,
+	"status":    true,
+	"timestamp": true,
+	"instance":  true,
+	"errors":    true,
+}
+
+// problemJSON has the fields of Problem without its methods, to avoid recursive marshalling.
+type problemJSON Problem
+
+// MarshalJSON serializes p with its type resolved against the base URI and its extensions as top-level members.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	p.Type = p.TypeURI()
+
+	data, err := json.Marshal(problemJSON(p))
+	if err != nil {
+		return nil, err
+	}
+
+	extensions := map[string]any{}
+	for name, value := range p.Extensions {
+		if !members[name] {
+			extensions[name] = value
+		}
+	}
+	if len(extensions) == 0 {
+		return data, nil
+	}
+
+	ext, err := json.Marshal(extensions)
+	if err != nil {
+		return nil, err
+	}
+
+	// merge both objects, keeping the standard members first
+	merged := bytes.TrimSuffix(data, []byte("}"))
+	merged = append(merged, ',')
+	return append(merged, ext[1:]...), nil
+}
+
+// UnmarshalJSON deserializes p, collecting all unknown members as extensions.
+// Types under the configured base URI are stored relative to it, so they can be compared to the types of the constructors.
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	var decoded problemJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+
+	var all map[string]json.RawMessage
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+
+	decoded.Extensions = nil
+	for name, raw := range all {
+		if members[name] {
+			continue
+		}
+
+		var value any
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return err
+		}
+		if decoded.Extensions == nil {
+			decoded.Extensions = map[string]any{}
+		}
+		decoded.Extensions[name] = value
+	}
+
+	*p = Problem(decoded)
+	p.Type = relativeType(p.Type)
+	return nil
+}
+
 func UnmarshalJSON(data []byte) *Problem {
 	if data == nil || len(data) == 0 {
 		return nil
@@ -62,11 +62,11 @@ func TestProblem_WriteToBroker(t *testing.T) {
 	fakeBroker := broker.NewFakeBroker()
 
 	// Create a channel to receive the published message
-	receivedMsg := make(chan []byte, 1)
+	receivedMsg := make(chan *nats.Msg, 1)
 
 	// Subscribe to messages
 	err := fakeBroker.Subscribe("test.subject", func(msg *nats.Msg) {
-		receivedMsg <- msg.Data
+		receivedMsg <- msg
 	})
 	require.NoError(t, err)
 
@@ -74,17 +74,22 @@ func TestProblem_WriteToBroker(t *testing.T) {
 	problem.WriteToBroker(fakeBroker, "test.subject")
 
 	// Wait for the message to be received
-	var data []byte
+	var msg *nats.Msg
 	select {
-	case data = <-receivedMsg:
+	case msg = <-receivedMsg:
 		// Got data
 	case <-time.After(time.Second):
 		t.Fatal("Timed out waiting for message")
 	}
 
+	// Verify the failure headers
+	assert.Equal(t, "400", msg.Header.Get(broker.ServiceErrorCodeHeader))
+	assert.Equal(t, "Test Error", msg.Header.Get(broker.ServiceErrorHeader))
+	assert.Equal(t, "application/problem+json", msg.Header.Get("Content-Type"))
+
 	// Verify the published data
 	var receivedProblem problems.Problem
-	err = json.Unmarshal(data, &receivedProblem)
+	err = json.Unmarshal(msg.Data, &receivedProblem)
 	require.NoError(t, err)
 
 	assert.Equal(t, problem.Type, receivedProblem.Type)
@@ -106,6 +111,7 @@ func TestMethodNotAllowed(t *testing.T) {
 	assert.Contains(t, problem.Detail, "GET")
 	assert.Contains(t, problem.Detail, "PUT")
 	assert.Equal(t, http.StatusMethodNotAllowed, problem.Status)
+	assert.Equal(t, "GET, PUT", problem.Header.Get("Allow"))
 	assert.WithinDuration(t, time.Now(), problem.Timestamp, time.Second)
 }
 
@@ -144,7 +150,11 @@ func TestUnauthorized(t *testing.T) {
 	assert.Equal(t, "Unauthorized", problem.Title)
 	assert.Contains(t, problem.Detail, "Authentication")
 	assert.Equal(t, http.StatusUnauthorized, problem.Status)
+	assert.Equal(t, []string{problems.DefaultChallenge}, problem.Header.Values("WWW-Authenticate"))
 	assert.WithinDuration(t, time.Now(), problem.Timestamp, time.Second)
+
+	problem = problems.Unauthorized(`Basic realm="api"`, `Bearer realm="api"`)
+	assert.Equal(t, []string{`Basic realm="api"`, `Bearer realm="api"`}, problem.Header.Values("WWW-Authenticate"))
 }
 
 func TestForbidden(t *testing.T) {
@@ -230,3 +240,136 @@ func TestNotImplemented(t *testing.T) {
 	assert.Equal(t, http.StatusNotImplemented, problem.Status)
 	assert.WithinDuration(t, time.Now(), problem.Timestamp, time.Second)
 }
+
+func TestStatusProblems(t *testing.T) {
+	tests := []struct {
+		problem *problems.Problem
+		status  int
+		typ     string
+	}{
+		{problems.RequestTimeout(), http.StatusRequestTimeout, "RequestTimeout"},
+		{problems.Gone("User", "12345"), http.StatusGone, "Gone"},
+		{problems.PreconditionFailed("If-Match"), http.StatusPreconditionFailed, "PreconditionFailed"},
+		{problems.ContentTooLarge(1024), http.StatusRequestEntityTooLarge, "ContentTooLarge"},
+		{problems.UnprocessableContent("The order has already been shipped."), http.StatusUnprocessableEntity, "UnprocessableContent"},
+		{problems.Locked("User", "12345"), http.StatusLocked, "Locked"},
+		{problems.BadGateway("The payment service failed."), http.StatusBadGateway, "BadGateway"},
+		{problems.ServiceUnavailable(0), http.StatusServiceUnavailable, "ServiceUnavailable"},
+		{problems.GatewayTimeout(), http.StatusGatewayTimeout, "GatewayTimeout"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.typ, func(t *testing.T) {
+			assert.Equal(t, tt.typ, tt.problem.Type)
+			assert.Equal(t, tt.status, tt.problem.Status)
+			assert.NotEmpty(t, tt.problem.Title)
+			assert.NotEmpty(t, tt.problem.Detail)
+
+			_, ok := problems.DefaultRegistry.Lookup(tt.typ)
+			assert.True(t, ok)
+		})
+	}
+
+	assert.Equal(t, "User gone", problems.Gone("User", "12345").Title)
+	assert.Equal(t, int64(1024), problems.ContentTooLarge(1024).Extensions["limit"])
+	assert.Empty(t, problems.ServiceUnavailable(0).Header.Get("Retry-After"))
+	assert.Equal(t, "120", problems.ServiceUnavailable(2*time.Minute).Header.Get("Retry-After"))
+}
+
+func TestProblem_WithRetryAfter(t *testing.T) {
+	problem := problems.TooManyRequests().WithRetryAfter(1500 * time.Millisecond)
+	assert.Equal(t, "2", problem.Header.Get("Retry-After"))
+
+	retryAfter, ok := problem.RetryAfter()
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Second, retryAfter)
+}
+
+func TestProblem_WriteToHTTP_Header(t *testing.T) {
+	rr := httptest.NewRecorder()
+	rr.Header().Set("Allow", "DELETE")
+
+	problem := problems.MethodNotAllowed(http.MethodPost, []string{http.MethodGet, http.MethodHead}).
+		WithHeader("Content-Type", "text/html")
+	problem.WriteToHTTP(rr)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	assert.Equal(t, []string{"GET, HEAD"}, rr.Header().Values("Allow"))
+	assert.Equal(t, "application/problem+json", rr.Header().Get("Content-Type"))
+
+	rr = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	problems.ServiceUnavailable(30*time.Second).WriteNegotiated(rr, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.Equal(t, "30", rr.Header().Get("Retry-After"))
+}
+
+func TestProblem_InstanceAndExtensions(t *testing.T) {
+	problem := problems.NotFound("User", "12345").
+		WithInstance("/users/12345").
+		WithExtension("userId", "12345").
+		WithExtension("attempts", 3).
+		WithExtension("status", 200)
+
+	data, err := json.Marshal(problem)
+	require.NoError(t, err)
+
+	var raw map[string]any
+	require.NoError(t, json.Unmarshal(data, &raw))
+	assert.Equal(t, "/users/12345", raw["instance"])
+	assert.Equal(t, "12345", raw["userId"])
+	assert.Equal(t, float64(3), raw["attempts"])
+	// extensions can't override standard members
+	assert.Equal(t, float64(http.StatusNotFound), raw["status"])
+
+	var decoded problems.Problem
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, "NotFound", decoded.Type)
+	assert.Equal(t, "/users/12345", decoded.Instance)
+	assert.Equal(t, map[string]any{"userId": "12345", "attempts": float64(3)}, decoded.Extensions)
+}
+
+func TestProblem_WithoutInstance(t *testing.T) {
+	data, err := json.Marshal(problems.Forbidden())
+	require.NoError(t, err)
+
+	var raw map[string]any
+	require.NoError(t, json.Unmarshal(data, &raw))
+	assert.NotContains(t, raw, "instance")
+
+	decoded := problems.UnmarshalJSON(data)
+	require.NotNil(t, decoded)
+	assert.Nil(t, decoded.Extensions)
+}
+
+func TestSetBaseURI(t *testing.T) {
+	t.Cleanup(func() { _ = problems.SetBaseURI("") })
+
+	assert.Error(t, problems.SetBaseURI("/problems/"))
+	assert.Error(t, problems.SetBaseURI("://invalid"))
+
+	require.NoError(t, problems.SetBaseURI("https://example.com/problems"))
+	assert.Equal(t, "https://example.com/problems/", problems.BaseURI())
+
+	problem := problems.NotFound("User", "12345")
+	assert.Equal(t, "NotFound", problem.Type)
+	assert.Equal(t, "https://example.com/problems/NotFound", problem.TypeURI())
+
+	rr := httptest.NewRecorder()
+	problem.WriteToHTTP(rr)
+
+	var raw map[string]any
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &raw))
+	assert.Equal(t, "https://example.com/problems/NotFound", raw["type"])
+
+	decoded := problems.UnmarshalJSON(rr.Body.Bytes())
+	require.NotNil(t, decoded)
+	assert.Equal(t, "NotFound", decoded.Type)
+
+	// absolute types are kept as they are
+	absolute := &problems.Problem{Type: "https://other.example.com/problems/Conflict"}
+	assert.Equal(t, "https://other.example.com/problems/Conflict", absolute.TypeURI())
+
+	assert.Equal(t, problems.AboutBlank, (&problems.Problem{}).TypeURI())
+}
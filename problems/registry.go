@@ -0,0 +1,277 @@
+package problems
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Definition declares a problem type.
+type Definition struct {
+	// Type is the type of the problems, relative to the base URI (e.g. "NotFound") or absolute.
+	Type   string
+	Title  string
+	Status int
+	// Description explains when the problem occurs and how clients can resolve it. It is shown on the documentation page of the type.
+	Description string
+	// Extensions maps the names of the extension members problems of this type carry to their JSON Schema, e.g. {"type": "integer"}.
+	Extensions map[string]map[string]any
+}
+
+// ProblemType is a registered problem type, which creates problems with its type, title and status.
+type ProblemType struct {
+	Definition
+}
+
+// New creates a problem of type t with the given detail.
+// In development mode, server errors record the stack trace of their creation, see SetMode.
+func (t *ProblemType) New(detail string) *Problem {
+	p := &Problem{
+		Type:      t.Type,
+		Title:     t.Title,
+		Detail:    detail,
+		Status:    t.Status,
+		Timestamp: time.Now(),
+	}
+	if t.Status >= http.StatusInternalServerError && currentMode() == ModeDevelopment {
+		p.stack = string(debug.Stack())
+	}
+
+	return p
+}
+
+// Newf is like New, but formats the detail according to a format specifier.
+func (t *ProblemType) Newf(format string, args ...any) *Problem {
+	return t.New(fmt.Sprintf(format, args...))
+}
+
+// URI returns the type resolved against the base URI, see SetBaseURI.
+func (t *ProblemType) URI() string {
+	return (&Problem{Type: t.Type}).TypeURI()
+}
+
+// Registry holds the problem types of an application, so they can be documented.
+type Registry struct {
+	mu    sync.RWMutex
+	types map[string]*ProblemType
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		types: map[string]*ProblemType{},
+	}
+}
+
+// DefaultRegistry holds the problem types of this package and all types registered with MustRegister.
+var DefaultRegistry = NewRegistry()
+
+// MustRegister registers def with the DefaultRegistry. It panics if the type is already registered,
+// so duplicate types are detected when the program starts. Use it to declare problem types as package variables.
+func MustRegister(def Definition) *ProblemType {
+	t, err := DefaultRegistry.Register(def)
+	if err != nil {
+		panic(err)
+	}
+
+	return t
+}
+
+// Register adds a problem type. It returns an error if the type is empty, its status is not an error status
+// or the type is already registered.
+func (r *Registry) Register(def Definition) (*ProblemType, error) {
+	if def.Type == "" {
+		return nil, fmt.Errorf("problem type must not be empty")
+	}
+	if def.Status < 400 || def.Status > 599 {
+		return nil, fmt.Errorf("status %d of problem type '%s' is not an error status", def.Status, def.Type)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.types[def.Type]; ok {
+		return nil, fmt.Errorf("problem type '%s' is already registered", def.Type)
+	}
+
+	t := &ProblemType{Definition: def}
+	r.types[def.Type] = t
+	return t, nil
+}
+
+// Lookup returns the registered type of problemType.
+func (r *Registry) Lookup(problemType string) (*ProblemType, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.types[problemType]
+	return t, ok
+}
+
+// Types returns all registered types sorted by type.
+func (r *Registry) Types() []*ProblemType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	types := make([]*ProblemType, 0, len(r.types))
+	for _, t := range r.types {
+		types = append(types, t)
+	}
+	slices.SortFunc(types, func(a, b *ProblemType) int {
+		return strings.Compare(a.Type, b.Type)
+	})
+
+	return types
+}
+
+// OpenAPIComponents exports the registered types as OpenAPI 3.1 components.
+// The result contains the "Problem" schema and a response per type, which can be referenced as "#/components/responses/<type>".
+// Merge it into the components of your API description.
+func (r *Registry) OpenAPIComponents() map[string]any {
+	responses := map[string]any{}
+	for _, t := range r.Types() {
+		properties := map[string]any{
+			"type":   map[string]any{"const": t.URI()},
+			"status": map[string]any{"const": t.Status},
+		}
+		for name, schema := range t.Extensions {
+			properties[name] = schema
+		}
+
+		responses[t.Type] = map[string]any{
+			"description": t.Title,
+			"content": map[string]any{
+				ContentTypeJSON: map[string]any{
+					"schema": map[string]any{
+						"allOf": []any{
+							map[string]any{"$ref": "#/components/schemas/Problem"},
+							map[string]any{"type": "object", "properties": properties},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	return map[string]any{
+		"schemas": map[string]any{
+			"Problem": problemSchema,
+		},
+		"responses": responses,
+	}
+}
+
+var problemSchema = map[string]any{
+	"type":                 "object",
+	"description":          "A problem details object as defined in RFC 9457.",
+	"additionalProperties": true,
+	"properties": map[string]any{
+		"type":      map[string]any{"type": "string", "format": "uri-reference"},
+		"title":     map[string]any{"type": "string"},
+		"detail":    map[string]any{"type": "string"},
+		"status":    map[string]any{"type": "integer", "minimum": 400, "maximum": 599},
+		"instance":  map[string]any{"type": "string", "format": "uri-reference"},
+		"timestamp": map[string]any{"type": "string", "format": "date-time"},
+		"errors": map[string]any{
+			"type": "array",
+			"items": map[string]any{
+				"type":     "object",
+				"required": []string{"pointer", "detail"},
+				"properties": map[string]any{
+					"pointer": map[string]any{"type": "string", "format": "json-pointer"},
+					"detail":  map[string]any{"type": "string"},
+				},
+			},
+		},
+	},
+}
+
+var docsTemplate = template.Must(template.New("docs").Funcs(template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{if .Type}}{{.Type.Title}}{{else}}Problem types{{end}}</title>
+</head>
+<body>
+{{- with .Type}}
+<h1>{{.Title}}</h1>
+<dl>
+<dt>Type</dt><dd><code>{{.URI}}</code></dd>
+<dt>Status</dt><dd>{{.Status}}</dd>
+</dl>
+{{- if .Description}}
+<p>{{.Description}}</p>
+{{- end}}
+{{- if .Extensions}}
+<h2>Extension members</h2>
+<table>
+<tr><th>Name</th><th>Schema</th></tr>
+{{- range $name, $schema := .Extensions}}
+<tr><td><code>{{$name}}</code></td><td><code>{{json $schema}}</code></td></tr>
+{{- end}}
+</table>
+{{- end}}
+{{- else}}
+<h1>Problem types</h1>
+<ul>
+{{- range .Types}}
+<li><a href="{{.Type}}">{{.Title}}</a> ({{.Status}})</li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
+
+// DocsHandler serves a documentation page for each registered type at the last path segment of its type URI,
+// and an index of all types at the root. Mount it at the path of the base URI, e.g.
+// http.Handle("/problems/", http.StripPrefix("/problems/", registry.DocsHandler())).
+func (r *Registry) DocsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodGet && req.Method != http.MethodHead {
+			MethodNotAllowed(req.Method, []string{http.MethodGet, http.MethodHead}).WriteNegotiated(w, req)
+			return
+		}
+
+		data := struct {
+			Type  *ProblemType
+			Types []*ProblemType
+		}{}
+
+		name := strings.Trim(req.URL.Path, "/")
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			name = name[i+1:]
+		}
+
+		if name == "" {
+			data.Types = r.Types()
+		} else {
+			t, ok := r.Lookup(name)
+			if !ok {
+				NotFound("Problem type", name).WriteNegotiated(w, req)
+				return
+			}
+			data.Type = t
+		}
+
+		var buf bytes.Buffer
+		if err := docsTemplate.Execute(&buf, data); err != nil {
+			InternalServerError("The documentation could not be rendered.").WithCause(err).WriteToHTTP(w)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(buf.Bytes())
+	})
+}
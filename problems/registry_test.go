@@ -0,0 +1,128 @@
+package problems_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OliverSchlueter/goutils/problems"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRegistry(t *testing.T) (*problems.Registry, *problems.ProblemType) {
+	registry := problems.NewRegistry()
+
+	quotaExceeded, err := registry.Register(problems.Definition{
+		Type:        "QuotaExceeded",
+		Title:       "Quota exceeded",
+		Status:      http.StatusForbidden,
+		Description: "The account has used up its storage quota.",
+		Extensions: map[string]map[string]any{
+			"quota": {"type": "integer"},
+		},
+	})
+	require.NoError(t, err)
+
+	return registry, quotaExceeded
+}
+
+func TestRegistry_Register(t *testing.T) {
+	registry, quotaExceeded := newTestRegistry(t)
+
+	_, err := registry.Register(problems.Definition{Type: "QuotaExceeded", Status: http.StatusForbidden})
+	assert.ErrorContains(t, err, "already registered")
+
+	_, err = registry.Register(problems.Definition{Type: "", Status: http.StatusForbidden})
+	assert.Error(t, err)
+
+	_, err = registry.Register(problems.Definition{Type: "Ok", Status: http.StatusOK})
+	assert.Error(t, err)
+
+	problem := quotaExceeded.Newf("The account has used %d of %d bytes.", 10, 10)
+	assert.Equal(t, "QuotaExceeded", problem.Type)
+	assert.Equal(t, "Quota exceeded", problem.Title)
+	assert.Equal(t, http.StatusForbidden, problem.Status)
+	assert.Equal(t, "The account has used 10 of 10 bytes.", problem.Detail)
+
+	found, ok := registry.Lookup("QuotaExceeded")
+	require.True(t, ok)
+	assert.Same(t, quotaExceeded, found)
+}
+
+func TestMustRegister_Duplicate(t *testing.T) {
+	assert.Panics(t, func() {
+		problems.MustRegister(problems.Definition{Type: "NotFound", Status: http.StatusNotFound})
+	})
+
+	_, ok := problems.DefaultRegistry.Lookup("ValidationError")
+	assert.True(t, ok)
+}
+
+func TestRegistry_OpenAPIComponents(t *testing.T) {
+	require.NoError(t, problems.SetBaseURI("https://example.com/problems/"))
+	t.Cleanup(func() { _ = problems.SetBaseURI("") })
+
+	registry, _ := newTestRegistry(t)
+
+	data, err := json.Marshal(registry.OpenAPIComponents())
+	require.NoError(t, err)
+
+	var components struct {
+		Schemas   map[string]any `json:"schemas"`
+		Responses map[string]struct {
+			Description string `json:"description"`
+			Content     map[string]struct {
+				Schema struct {
+					AllOf []struct {
+						Ref        string                    `json:"$ref"`
+						Properties map[string]map[string]any `json:"properties"`
+					} `json:"allOf"`
+				} `json:"schema"`
+			} `json:"content"`
+		} `json:"responses"`
+	}
+	require.NoError(t, json.Unmarshal(data, &components))
+
+	assert.Contains(t, components.Schemas, "Problem")
+
+	response, ok := components.Responses["QuotaExceeded"]
+	require.True(t, ok)
+	assert.Equal(t, "Quota exceeded", response.Description)
+
+	schema := response.Content[problems.ContentTypeJSON].Schema
+	require.Len(t, schema.AllOf, 2)
+	assert.Equal(t, "#/components/schemas/Problem", schema.AllOf[0].Ref)
+	assert.Equal(t, "https://example.com/problems/QuotaExceeded", schema.AllOf[1].Properties["type"]["const"])
+	assert.Equal(t, float64(http.StatusForbidden), schema.AllOf[1].Properties["status"]["const"])
+	assert.Equal(t, "integer", schema.AllOf[1].Properties["quota"]["type"])
+}
+
+func TestRegistry_DocsHandler(t *testing.T) {
+	registry, _ := newTestRegistry(t)
+
+	mux := http.NewServeMux()
+	mux.Handle("/problems/", http.StripPrefix("/problems/", registry.DocsHandler()))
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/problems/QuotaExceeded", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), "<h1>Quota exceeded</h1>")
+	assert.Contains(t, rr.Body.String(), "The account has used up its storage quota.")
+	assert.Contains(t, rr.Body.String(), "<code>quota</code>")
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/problems/", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `<a href="QuotaExceeded">Quota exceeded</a>`)
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/problems/Unknown", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/problems/QuotaExceeded", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
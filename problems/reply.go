@@ -0,0 +1,71 @@
+package problems
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/OliverSchlueter/goutils/broker"
+	"github.com/nats-io/nats.go"
+)
+
+func init() {
+	broker.RegisterErrorDecoder(ContentTypeJSON, DecodeReply)
+	broker.RegisterErrorDecoder(ContentTypeXML, DecodeReply)
+}
+
+// PropagateReplies makes broker.Request return problems of other services as *Problem instead of *UpstreamError,
+// so From passes them on to the client as they are, like ClientConfiguration.Propagate.
+// It applies to all requests of the process.
+func PropagateReplies() {
+	propagate := func(_ string, msg *nats.Msg) error {
+		if problem := decodeReply(msg); problem != nil {
+			return problem
+		}
+		return nil
+	}
+
+	broker.RegisterErrorDecoder(ContentTypeJSON, propagate)
+	broker.RegisterErrorDecoder(ContentTypeXML, propagate)
+}
+
+// RespondToBroker replies p to the request msg, see WriteToBroker.
+func (p *Problem) RespondToBroker(b broker.Broker, msg *nats.Msg) {
+	if msg.Reply == "" {
+		slog.Warn("can't respond problem to message without reply subject", "subject", msg.Subject, "type", p.Type)
+		return
+	}
+
+	p.WriteToBroker(b, msg.Reply)
+}
+
+// DecodeReply decodes the problem of a failed reply msg to a request to subject. It returns nil if msg is not a problem reply.
+// The problem is returned as *UpstreamError with Method "REQUEST" and the subject as URL, so From maps it to a BadGateway problem.
+// This package registers it with broker.RegisterErrorDecoder, see PropagateReplies to pass the problems on instead.
+func DecodeReply(subject string, msg *nats.Msg) error {
+	problem := decodeReply(msg)
+	if problem == nil {
+		return nil
+	}
+
+	return &UpstreamError{Method: "REQUEST", URL: subject, Problem: problem}
+}
+
+func decodeReply(msg *nats.Msg) *Problem {
+	if msg.Header.Get(broker.ServiceErrorCodeHeader) == "" {
+		return nil
+	}
+
+	problem := Unmarshal(msg.Header.Get("Content-Type"), msg.Data)
+	if problem == nil {
+		return nil
+	}
+	if problem.Status == 0 {
+		problem.Status, _ = strconv.Atoi(msg.Header.Get(broker.ServiceErrorCodeHeader))
+	}
+	if retryAfter := msg.Header.Get("Retry-After"); retryAfter != "" {
+		problem.Header = http.Header{"Retry-After": {retryAfter}}
+	}
+
+	return problem
+}
@@ -0,0 +1,142 @@
+package problems_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/OliverSchlueter/goutils/broker"
+	"github.com/OliverSchlueter/goutils/problems"
+	"github.com/nats-io/nats.go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblem_RespondToBroker(t *testing.T) {
+	fb := broker.NewFakeBroker()
+
+	replies := make(chan *nats.Msg, 1)
+	require.NoError(t, fb.Subscribe("reply", func(msg *nats.Msg) {
+		replies <- msg
+	}))
+	fb.SetRequestHandler(func(msg *nats.Msg) (*nats.Msg, error) {
+		problems.ServiceUnavailable(time.Minute).RespondToBroker(fb, msg)
+		return <-replies, nil
+	})
+
+	msg, err := broker.Request(fb, "users.get", []byte("12345"))
+	require.Error(t, err)
+	assert.Equal(t, "503", msg.Header.Get(broker.ServiceErrorCodeHeader))
+	assert.Equal(t, "Service Unavailable", msg.Header.Get(broker.ServiceErrorHeader))
+	assert.NotEmpty(t, msg.Header.Get(problems.CorrelationIDHeader))
+
+	var upstream *problems.UpstreamError
+	require.True(t, errors.As(err, &upstream))
+	assert.Equal(t, "users.get", upstream.URL)
+	assert.Equal(t, "ServiceUnavailable", upstream.Problem.Type)
+	assert.Equal(t, http.StatusServiceUnavailable, upstream.Problem.Status)
+
+	retryAfter, ok := upstream.Problem.RetryAfter()
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, retryAfter)
+
+	// problems of other services are not passed on by default
+	assert.Equal(t, http.StatusBadGateway, problems.From(err).Status)
+}
+
+func TestPropagateReplies(t *testing.T) {
+	problems.PropagateReplies()
+	t.Cleanup(func() {
+		broker.RegisterErrorDecoder(problems.ContentTypeJSON, problems.DecodeReply)
+		broker.RegisterErrorDecoder(problems.ContentTypeXML, problems.DecodeReply)
+	})
+
+	fb := broker.NewFakeBroker()
+	replies := make(chan *nats.Msg, 1)
+	require.NoError(t, fb.Subscribe("reply", func(msg *nats.Msg) {
+		replies <- msg
+	}))
+	fb.SetRequestHandler(func(msg *nats.Msg) (*nats.Msg, error) {
+		problems.NotFound("User", "12345").RespondToBroker(fb, msg)
+		return <-replies, nil
+	})
+
+	_, err := broker.Request(fb, "users.get", []byte("12345"))
+
+	var problem *problems.Problem
+	require.True(t, errors.As(err, &problem))
+	assert.Same(t, problem, problems.From(err))
+	assert.Equal(t, http.StatusNotFound, problem.Status)
+}
+
+func TestProblem_WriteToBroker_TitleLineBreaks(t *testing.T) {
+	fb := broker.NewFakeBroker()
+
+	var received *nats.Msg
+	require.NoError(t, fb.Subscribe("reply", func(msg *nats.Msg) {
+		received = msg
+	}))
+
+	problem := problems.Forbidden()
+	problem.Title = "Bad\r\nX-Injected: true"
+	problem.WriteToBroker(fb, "reply")
+
+	require.NotNil(t, received)
+	assert.Equal(t, "Bad  X-Injected: true", received.Header.Get(broker.ServiceErrorHeader))
+}
+
+func TestProblem_RespondToBroker_NoReply(t *testing.T) {
+	fb := broker.NewFakeBroker()
+
+	received := false
+	require.NoError(t, fb.Subscribe("users.created", func(msg *nats.Msg) {
+		received = true
+	}))
+
+	problems.NotFound("User", "12345").RespondToBroker(fb, &nats.Msg{Subject: "users.created"})
+	assert.False(t, received)
+}
+
+func TestDecodeReply(t *testing.T) {
+	msg := nats.NewMsg("reply")
+	msg.Data = []byte(`{"type":"NotFound","title":"User not found"}`)
+	assert.NoError(t, problems.DecodeReply("users.get", msg))
+
+	msg.Header.Set("Content-Type", problems.ContentTypeJSON)
+	msg.Header.Set(broker.ServiceErrorCodeHeader, "404")
+
+	var upstream *problems.UpstreamError
+	require.True(t, errors.As(problems.DecodeReply("users.get", msg), &upstream))
+	assert.Equal(t, "REQUEST", upstream.Method)
+	assert.Equal(t, "users.get", upstream.URL)
+	assert.Equal(t, "NotFound", upstream.Problem.Type)
+	assert.Equal(t, http.StatusNotFound, upstream.Problem.Status)
+
+	msg.Data = []byte("not a problem")
+	assert.NoError(t, problems.DecodeReply("users.get", msg))
+}
+
+// headerlessBroker is a broker that can't publish headers.
+type headerlessBroker struct {
+	*broker.FakeBroker
+}
+
+// PublishMsg shadows the method of FakeBroker with another signature, so headerlessBroker is no MsgPublisher.
+func (headerlessBroker) PublishMsg() {}
+
+func TestProblem_WriteToBroker_WithoutHeaders(t *testing.T) {
+	b := headerlessBroker{broker.NewFakeBroker()}
+
+	var received []*nats.Msg
+	require.NoError(t, b.Subscribe("reply", func(msg *nats.Msg) {
+		received = append(received, msg)
+	}))
+
+	problems.NotFound("User", "12345").WriteToBroker(b, "reply")
+
+	require.Len(t, received, 1)
+	problem := problems.UnmarshalJSON(received[0].Data)
+	require.NotNil(t, problem)
+	assert.Equal(t, "NotFound", problem.Type)
+}
@@ -0,0 +1,91 @@
+package problems
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// AboutBlank is the type of problems without any further semantics than their status code.
+const AboutBlank = "about:blank"
+
+var (
+	baseURIMu sync.RWMutex
+	baseURI   *url.URL
+)
+
+// SetBaseURI sets the absolute URI relative problem types are resolved against, e.g. "https://example.com/problems/".
+// With a base URI of "https://example.com/problems/", the type "NotFound" is serialized as "https://example.com/problems/NotFound".
+// An empty uri resets the base URI, so types are serialized as they are.
+func SetBaseURI(uri string) error {
+	if uri == "" {
+		baseURIMu.Lock()
+		baseURI = nil
+		baseURIMu.Unlock()
+		return nil
+	}
+
+	u, err := url.Parse(uri)
+	if err != nil {
+		return fmt.Errorf("invalid base uri: %w", err)
+	}
+	if !u.IsAbs() {
+		return fmt.Errorf("base uri '%s' is not absolute", uri)
+	}
+	if !strings.HasSuffix(u.Path, "/") {
+		// without trailing slash, the last segment would be replaced when resolving types
+		u.Path += "/"
+	}
+
+	baseURIMu.Lock()
+	baseURI = u
+	baseURIMu.Unlock()
+	return nil
+}
+
+// BaseURI returns the base URI set by SetBaseURI, or an empty string if none is set.
+func BaseURI() string {
+	baseURIMu.RLock()
+	defer baseURIMu.RUnlock()
+
+	if baseURI == nil {
+		return ""
+	}
+	return baseURI.String()
+}
+
+// TypeURI returns the type of p resolved against the base URI.
+// Problems without type have the type "about:blank".
+func (p *Problem) TypeURI() string {
+	if p.Type == "" {
+		return AboutBlank
+	}
+
+	baseURIMu.RLock()
+	defer baseURIMu.RUnlock()
+
+	if baseURI == nil {
+		return p.Type
+	}
+
+	ref, err := url.Parse(p.Type)
+	if err != nil || ref.IsAbs() {
+		return p.Type
+	}
+
+	return baseURI.ResolveReference(ref).String()
+}
+
+// relativeType returns t relative to the base URI, or t itself if it is not under the base URI.
+func relativeType(t string) string {
+	base := BaseURI()
+	if base == "" {
+		return t
+	}
+
+	if rel, ok := strings.CutPrefix(t, base); ok && rel != "" {
+		return rel
+	}
+	return t
+}
@@ -0,0 +1,90 @@
+package problems
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FieldError describes why a single part of a request is invalid, see RFC 9457 section 3.
+type FieldError struct {
+	// Pointer is a JSON Pointer (RFC 6901) to the invalid member of the request, e.g. "/items/0/name".
+	Pointer string `json:"pointer"`
+	Detail  string `json:"detail"`
+}
+
+// ValidationErrors creates a Problem instance for HTTP 400 Bad Request errors with all errors of a request.
+func ValidationErrors(errs []FieldError) *Problem {
+	detail := "Validation failed for 1 field."
+	if len(errs) != 1 {
+		detail = fmt.Sprintf("Validation failed for %d fields.", len(errs))
+	}
+
+	p := TypeValidationError.New(detail)
+	p.Errors = errs
+	p.Params = map[string]any{"count": len(errs)}
+	return p
+}
+
+// Pointer builds a JSON Pointer from reference tokens, e.g. Pointer("items", 0, "name") returns "/items/0/name".
+// The tokens are raw member names and indexes, not pointers: '~' and '/' in them are escaped,
+// so Pointer("/email") returns "/~1email". Pass existing pointers to Validation.Add as they are.
+func Pointer(tokens ...any) string {
+	var sb strings.Builder
+	for _, token := range tokens {
+		s := fmt.Sprint(token)
+		s = strings.ReplaceAll(s, "~", "~0")
+		s = strings.ReplaceAll(s, "/", "~1")
+
+		sb.WriteByte('/')
+		sb.WriteString(s)
+	}
+
+	return sb.String()
+}
+
+// Validation accumulates validation failures, so all of them can be reported at once.
+type Validation struct {
+	errs []FieldError
+}
+
+func NewValidation() *Validation {
+	return &Validation{}
+}
+
+// Add records that the member at pointer is invalid.
+func (v *Validation) Add(pointer, detail string) *Validation {
+	v.errs = append(v.errs, FieldError{Pointer: pointer, Detail: detail})
+	return v
+}
+
+// Addf is like Add, but formats the detail according to a format specifier.
+func (v *Validation) Addf(pointer, format string, args ...any) *Validation {
+	return v.Add(pointer, fmt.Sprintf(format, args...))
+}
+
+// Check records the failure if ok is false.
+func (v *Validation) Check(ok bool, pointer, detail string) *Validation {
+	if !ok {
+		v.Add(pointer, detail)
+	}
+	return v
+}
+
+// HasErrors reports whether any failure has been recorded.
+func (v *Validation) HasErrors() bool {
+	return len(v.errs) > 0
+}
+
+// Errors returns all recorded failures.
+func (v *Validation) Errors() []FieldError {
+	return v.errs
+}
+
+// Problem returns a ValidationErrors problem with all recorded failures, or nil if there are none.
+func (v *Validation) Problem() *Problem {
+	if !v.HasErrors() {
+		return nil
+	}
+
+	return ValidationErrors(v.errs)
+}
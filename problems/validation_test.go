@@ -0,0 +1,61 @@
+package problems_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/OliverSchlueter/goutils/problems"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPointer(t *testing.T) {
+	assert.Equal(t, "", problems.Pointer())
+	assert.Equal(t, "/items/0/name", problems.Pointer("items", 0, "name"))
+	assert.Equal(t, "/a~1b/m~0n", problems.Pointer("a/b", "m~n"))
+	assert.Equal(t, "/~1email", problems.Pointer("/email"), "Tokens should be escaped, not taken as pointers")
+}
+
+func TestValidation(t *testing.T) {
+	v := problems.NewValidation()
+	assert.Nil(t, v.Problem())
+
+	v.Check(true, "/email", "is required").
+		Check(false, "/name", "is required").
+		Addf(problems.Pointer("items", 2), "must have a quantity of at least %d", 1)
+	require.True(t, v.HasErrors())
+
+	problem := v.Problem()
+	require.NotNil(t, problem)
+	assert.Equal(t, "ValidationError", problem.Type)
+	assert.Equal(t, http.StatusBadRequest, problem.Status)
+	assert.Equal(t, "Validation failed for 2 fields.", problem.Detail)
+	assert.Equal(t, []problems.FieldError{
+		{Pointer: "/name", Detail: "is required"},
+		{Pointer: "/items/2", Detail: "must have a quantity of at least 1"},
+	}, problem.Errors)
+
+	data, err := json.Marshal(problem)
+	require.NoError(t, err)
+
+	var raw map[string]any
+	require.NoError(t, json.Unmarshal(data, &raw))
+	assert.Equal(t, []any{
+		map[string]any{"pointer": "/name", "detail": "is required"},
+		map[string]any{"pointer": "/items/2", "detail": "must have a quantity of at least 1"},
+	}, raw["errors"])
+
+	decoded := problems.UnmarshalJSON(data)
+	require.NotNil(t, decoded)
+	assert.Equal(t, problem.Errors, decoded.Errors)
+	assert.Nil(t, decoded.Extensions)
+}
+
+func TestValidationError_Errors(t *testing.T) {
+	problem := problems.ValidationError("email", "invalid format")
+	assert.Equal(t, []problems.FieldError{{Pointer: "/email", Detail: "invalid format"}}, problem.Errors)
+
+	problem = problems.ValidationError("/items/0/name", "is required")
+	assert.Equal(t, []problems.FieldError{{Pointer: "/items/0/name", Detail: "is required"}}, problem.Errors)
+}
@@ -0,0 +1,128 @@
+// Package validatorproblems reports the results of github.com/go-playground/validator as problems.
+package validatorproblems
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/OliverSchlueter/goutils/problems"
+	"github.com/go-playground/validator/v10"
+)
+
+// NewValidator returns a validator for `validate` struct tags that reports fields by their JSON names,
+// so the pointers of FromValidator match the request body.
+func NewValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(JSONTagName)
+	return v
+}
+
+// JSONTagName returns the name of field in JSON, or "-" if it is not serialized.
+// Use it with validator.Validate.RegisterTagNameFunc.
+func JSONTagName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return "-"
+	}
+	if name == "" {
+		return field.Name
+	}
+
+	return name
+}
+
+// FromValidator converts the result of validator.Validate.Struct into a problems.ValidationErrors problem.
+// It returns nil if err is nil and an InternalServerError if err is not a validation result, e.g. because a nil struct was validated.
+func FromValidator(err error) *problems.Problem {
+	if err == nil {
+		return nil
+	}
+
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return problems.InternalServerError("The request could not be validated.")
+	}
+
+	errs := make([]problems.FieldError, 0, len(fieldErrs))
+	for _, fe := range fieldErrs {
+		errs = append(errs, problems.FieldError{
+			Pointer: namespacePointer(fe.Namespace()),
+			Detail:  validationDetail(fe),
+		})
+	}
+
+	return problems.ValidationErrors(errs)
+}
+
+// namespacePointer converts a validator namespace like "Order.items[0].name" into "/items/0/name".
+func namespacePointer(namespace string) string {
+	// the first segment is the name of the validated struct itself
+	_, path, _ := strings.Cut(namespace, ".")
+	if path == "" {
+		return ""
+	}
+
+	var tokens []any
+	for _, segment := range strings.Split(path, ".") {
+		name, rest, _ := strings.Cut(segment, "[")
+		tokens = append(tokens, name)
+
+		for rest != "" {
+			var index string
+			index, rest, _ = strings.Cut(rest, "]")
+			tokens = append(tokens, index)
+			rest = strings.TrimPrefix(rest, "[")
+		}
+	}
+
+	return problems.Pointer(tokens...)
+}
+
+func validationDetail(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url", "uri", "http_url":
+		return "must be a valid URL"
+	case "uuid", "uuid4":
+		return "must be a valid UUID"
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", fe.Param())
+	case "len":
+		return fmt.Sprintf("must have a length of %s", fe.Param())
+	case "min", "gte":
+		if isNumber(fe.Kind()) {
+			return fmt.Sprintf("must be at least %s", fe.Param())
+		}
+		return fmt.Sprintf("must have a length of at least %s", fe.Param())
+	case "max", "lte":
+		if isNumber(fe.Kind()) {
+			return fmt.Sprintf("must be at most %s", fe.Param())
+		}
+		return fmt.Sprintf("must have a length of at most %s", fe.Param())
+	case "gt":
+		return fmt.Sprintf("must be greater than %s", fe.Param())
+	case "lt":
+		return fmt.Sprintf("must be less than %s", fe.Param())
+	default:
+		if fe.Param() != "" {
+			return fmt.Sprintf("failed the '%s=%s' validation", fe.Tag(), fe.Param())
+		}
+		return fmt.Sprintf("failed the '%s' validation", fe.Tag())
+	}
+}
+
+func isNumber(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
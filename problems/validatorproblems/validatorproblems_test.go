@@ -0,0 +1,50 @@
+package validatorproblems_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/OliverSchlueter/goutils/problems"
+	"github.com/OliverSchlueter/goutils/problems/validatorproblems"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type orderItem struct {
+	Name     string `json:"name" validate:"required"`
+	Quantity int    `json:"quantity" validate:"min=1"`
+}
+
+type order struct {
+	Email string      `json:"email" validate:"required,email"`
+	Notes string      `json:"notes,omitempty" validate:"max=5"`
+	Items []orderItem `json:"items" validate:"required,dive"`
+}
+
+func TestFromValidator(t *testing.T) {
+	validate := validatorproblems.NewValidator()
+
+	assert.Nil(t, validatorproblems.FromValidator(validate.Struct(order{
+		Email: "jane@example.com",
+		Items: []orderItem{{Name: "book", Quantity: 1}},
+	})))
+
+	problem := validatorproblems.FromValidator(validate.Struct(order{
+		Email: "not an email",
+		Notes: "far too long",
+		Items: []orderItem{{Name: "book", Quantity: 1}, {Quantity: 0}},
+	}))
+	require.NotNil(t, problem)
+	assert.Equal(t, "ValidationError", problem.Type)
+	assert.Equal(t, "Validation failed for 4 fields.", problem.Detail)
+	assert.Equal(t, []problems.FieldError{
+		{Pointer: "/email", Detail: "must be a valid email address"},
+		{Pointer: "/notes", Detail: "must have a length of at most 5"},
+		{Pointer: "/items/1/name", Detail: "is required"},
+		{Pointer: "/items/1/quantity", Detail: "must be at least 1"},
+	}, problem.Errors)
+
+	problem = validatorproblems.FromValidator(validate.Struct(nil))
+	require.NotNil(t, problem)
+	assert.Equal(t, http.StatusInternalServerError, problem.Status)
+}
@@ -0,0 +1,111 @@
+// Package databasetest is a conformance test suite for implementations of ratelimit.DB.
+package databasetest
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OliverSchlueter/goutils/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// NewDB creates an empty database.
+type NewDB func(t *testing.T) ratelimit.DB
+
+// Run runs all tests of the suite against the databases created by newDB.
+func Run(t *testing.T, newDB NewDB) {
+	t.Run("Take", func(t *testing.T) { TestTake(t, newDB) })
+	t.Run("TakeWithoutRefill", func(t *testing.T) { TestTakeWithoutRefill(t, newDB) })
+	t.Run("TakeConcurrent", func(t *testing.T) { TestTakeConcurrent(t, newDB) })
+}
+
+func TestTake(t *testing.T, newDB NewDB) {
+	db := newDB(t)
+
+	now := time.Now()
+	take := func(client string, cost int, elapsed time.Duration) (bool, int) {
+		allowed, remaining, _, err := db.Take(client, cost, 1, 3, now.Add(elapsed))
+		require.NoError(t, err)
+		return allowed, remaining
+	}
+
+	for i := 2; i >= 0; i-- {
+		allowed, remaining := take("alice", 1, 0)
+		assert.True(t, allowed)
+		assert.Equal(t, i, remaining)
+	}
+
+	allowed, remaining, reset, err := db.Take("alice", 1, 1, 3, now)
+	require.NoError(t, err)
+	assert.False(t, allowed, "Empty buckets should not allow taking tokens")
+	assert.Equal(t, 0, remaining)
+	assert.WithinDuration(t, now.Add(3*time.Second), reset, time.Millisecond, "Empty buckets should be full after burst / rate")
+
+	allowed, remaining = take("alice", 1, time.Second)
+	assert.True(t, allowed, "Buckets should be refilled by rate tokens per second")
+	assert.Equal(t, 0, remaining)
+
+	allowed, _ = take("alice", 1, 1500*time.Millisecond)
+	assert.False(t, allowed, "Buckets should not be refilled by partial tokens")
+
+	allowed, remaining = take("alice", 2, time.Minute)
+	assert.True(t, allowed)
+	assert.Equal(t, 1, remaining, "Buckets should not be refilled beyond the burst")
+
+	allowed, _ = take("alice", 4, 2*time.Minute)
+	assert.False(t, allowed, "Taking more than the burst should never be allowed")
+
+	allowed, remaining = take("bob", 3, 0)
+	assert.True(t, allowed, "Buckets of other clients should not be changed")
+	assert.Equal(t, 0, remaining)
+}
+
+func TestTakeWithoutRefill(t *testing.T, newDB NewDB) {
+	db := newDB(t)
+
+	now := time.Now()
+	allowed, remaining, reset, err := db.Take("alice", 2, 0, 2, now)
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 0, remaining)
+	assert.True(t, reset.IsZero(), "Buckets without refill should never be full again")
+
+	// the bucket must neither be refilled nor be deleted, which would refill it
+	allowed, remaining, _, err = db.Take("alice", 1, 0, 2, now.Add(24*time.Hour))
+	require.NoError(t, err)
+	assert.False(t, allowed, "Buckets should not be refilled with a rate of 0")
+	assert.Equal(t, 0, remaining)
+}
+
+func TestTakeConcurrent(t *testing.T, newDB NewDB) {
+	const (
+		burst    = 20
+		requests = 500
+	)
+
+	db := newDB(t)
+
+	now := time.Now()
+	var (
+		wg      sync.WaitGroup
+		allowed atomic.Int64
+	)
+	for range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ok, _, _, err := db.Take("alice", 1, 0, burst, now)
+			assert.NoError(t, err)
+			if ok {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(burst), allowed.Load(), "Concurrent requests should consume exactly the burst")
+}
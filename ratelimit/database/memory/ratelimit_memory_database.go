@@ -1,73 +1,73 @@
 package memory
 
 import (
-	"reflect"
+	"math"
+	"sync"
 	"time"
-
-	"github.com/dgraph-io/ristretto/v2"
 )
 
-var (
-	ttl        = 15 * time.Minute
-	sizeOfTime = int64(reflect.TypeOf(time.Time{}).Size())
-)
+var sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	refill time.Time
+	reset  time.Time
+}
 
+// DB holds the token buckets of a single process. All takes are serialized by a mutex,
+// so concurrent requests of a client can't over-consume its tokens.
 type DB struct {
-	maxTokens   int
-	tokens      *ristretto.Cache[string, int]
-	refillTimes *ristretto.Cache[string, time.Time]
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	nextSweep time.Time
 }
 
-func NewDB(maxTokens int) *DB {
-	tokens, err := ristretto.NewCache(&ristretto.Config[string, int]{
-		NumCounters: 500 * 10,         // x10 of expected number of elements when full
-		MaxCost:     16 * 1024 * 1024, // 16 MB
-		BufferItems: 64,               // keep 64
-	})
-	if err != nil {
-		panic(err)
+func NewDB() *DB {
+	return &DB{
+		buckets: map[string]*bucket{},
 	}
+}
 
-	refillTimes, err := ristretto.NewCache(&ristretto.Config[string, time.Time]{
-		NumCounters: 500 * 10,         // x10 of expected number of elements when full
-		MaxCost:     16 * 1024 * 1024, // 16 MB
-		BufferItems: 64,               // keep 64
-	})
-	if err != nil {
-		panic(err)
-	}
+func (db *DB) Take(client string, cost int, rate float64, burst int, now time.Time) (bool, int, time.Time, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
 
-	return &DB{
-		maxTokens:   maxTokens,
-		tokens:      tokens,
-		refillTimes: refillTimes,
+	db.sweep(now)
+
+	b, ok := db.buckets[client]
+	if !ok {
+		b = &bucket{tokens: float64(burst), refill: now}
+		db.buckets[client] = b
+	}
+	if now.After(b.refill) {
+		b.tokens = min(float64(burst), b.tokens+now.Sub(b.refill).Seconds()*rate)
+		b.refill = now
 	}
-}
 
-func (db *DB) GetTokens(client string) (int, error) {
-	count, exists := db.tokens.Get(client)
-	if !exists {
-		return db.maxTokens, nil
+	allowed := b.tokens >= float64(cost)
+	if allowed {
+		b.tokens -= float64(cost)
 	}
 
-	return count, nil
-}
+	b.reset = time.Time{}
+	if rate > 0 {
+		b.reset = now.Add(time.Duration((float64(burst) - b.tokens) / rate * float64(time.Second)))
+	}
 
-func (db *DB) SetTokens(client string, tokens int) error {
-	db.tokens.SetWithTTL(client, tokens, 4, ttl)
-	return nil
+	return allowed, int(math.Floor(b.tokens)), b.reset, nil
 }
 
-func (db *DB) GetLastRefill(client string) (time.Time, error) {
-	t, exists := db.refillTimes.Get(client)
-	if !exists {
-		return time.Now().Add((-5) * time.Minute), nil // return a time in the past to trigger immediate refill
+// sweep deletes the buckets that are full again, as they are equal to missing buckets.
+// Buckets that are never refilled are kept, like in the other databases.
+func (db *DB) sweep(now time.Time) {
+	if now.Before(db.nextSweep) {
+		return
 	}
+	db.nextSweep = now.Add(sweepInterval)
 
-	return t, nil
-}
-
-func (db *DB) SetLastRefill(client string, t time.Time) error {
-	db.refillTimes.SetWithTTL(client, t, sizeOfTime, ttl)
-	return nil
+	for client, b := range db.buckets {
+		if !b.reset.IsZero() && !b.reset.After(now) {
+			delete(db.buckets, client)
+		}
+	}
 }
@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/OliverSchlueter/goutils/ratelimit"
+	"github.com/OliverSchlueter/goutils/ratelimit/database/databasetest"
+	"github.com/OliverSchlueter/goutils/ratelimit/database/memory"
+)
+
+func TestDB(t *testing.T) {
+	databasetest.Run(t, func(t *testing.T) ratelimit.DB {
+		return memory.NewDB()
+	})
+}
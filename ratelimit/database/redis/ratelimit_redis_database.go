@@ -0,0 +1,89 @@
+// Package redis stores the token buckets of rate limits in Redis, so all replicas of a service share them.
+package redis
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// takeScript refills the bucket and consumes the tokens in one step, so concurrent requests can't race.
+// The bucket expires when it is full again, as a missing bucket is full. Buckets without refill never expire.
+// KEYS[1] = bucket key, ARGV[1] = cost, ARGV[2] = tokens per second, ARGV[3] = burst, ARGV[4] = now in microseconds
+var takeScript = goredis.NewScript(`
+local cost = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local burst = tonumber(ARGV[3])
+local now = tonumber(ARGV[4])
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "refill")
+local tokens = tonumber(bucket[1])
+local refill = tonumber(bucket[2])
+if tokens == nil or refill == nil then
+	tokens = burst
+	refill = now
+end
+if now > refill then
+	tokens = math.min(burst, tokens + (now - refill) / 1000000 * rate)
+	refill = now
+end
+
+local allowed = 0
+if tokens >= cost then
+	tokens = tokens - cost
+	allowed = 1
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "refill", tostring(refill))
+if rate > 0 then
+	redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1)
+else
+	-- buckets that are never refilled must not expire, which would refill them
+	redis.call("PERSIST", KEYS[1])
+end
+return {tostring(allowed), tostring(tokens)}
+`)
+
+type DB struct {
+	client goredis.Scripter
+	prefix string
+}
+
+type Configuration struct {
+	Client goredis.Scripter
+	// Prefix is prepended to the keys of all buckets. Defaults to "ratelimit:".
+	Prefix string
+}
+
+func NewDB(cfg Configuration) *DB {
+	if cfg.Prefix == "" {
+		cfg.Prefix = "ratelimit:"
+	}
+
+	return &DB{
+		client: cfg.Client,
+		prefix: cfg.Prefix,
+	}
+}
+
+func (db *DB) Take(client string, cost int, rate float64, burst int, now time.Time) (bool, int, time.Time, error) {
+	res, err := takeScript.Run(context.Background(), db.client, []string{db.prefix + client}, cost, rate, burst, now.UnixMicro()).StringSlice()
+	if err != nil {
+		return false, 0, time.Time{}, err
+	}
+
+	tokens, err := strconv.ParseFloat(res[1], 64)
+	if err != nil {
+		return false, 0, time.Time{}, err
+	}
+
+	var reset time.Time
+	if rate > 0 {
+		reset = now.Add(time.Duration((float64(burst) - tokens) / rate * float64(time.Second)))
+	}
+
+	return res[0] == "1", int(math.Floor(tokens)), reset, nil
+}
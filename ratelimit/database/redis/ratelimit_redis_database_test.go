@@ -0,0 +1,90 @@
+package redis_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OliverSchlueter/goutils/ratelimit"
+	"github.com/OliverSchlueter/goutils/ratelimit/database/databasetest"
+	"github.com/OliverSchlueter/goutils/ratelimit/database/redis"
+	"github.com/alicebob/miniredis/v2"
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDB(t *testing.T) (*redis.DB, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rc := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rc.Close() })
+
+	return redis.NewDB(redis.Configuration{
+		Client: rc,
+	}), mr
+}
+
+func TestDB(t *testing.T) {
+	databasetest.Run(t, func(t *testing.T) ratelimit.DB {
+		db, _ := newDB(t)
+		return db
+	})
+}
+
+func TestDB_Take_Expiry(t *testing.T) {
+	db, mr := newDB(t)
+
+	allowed, _, _, err := db.Take("alice", 4, 2, 10, time.Now())
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.True(t, mr.Exists("ratelimit:alice"))
+
+	// the bucket is full again after 4 / 2 seconds, so it is not needed anymore
+	mr.FastForward(2*time.Second + time.Millisecond)
+	assert.False(t, mr.Exists("ratelimit:alice"))
+}
+
+func TestService_CheckAndConsume_Replicas(t *testing.T) {
+	const (
+		replicas = 4
+		burst    = 20
+		requests = 200
+	)
+
+	mr := miniredis.RunT(t)
+
+	// every replica has its own connection, so only the script can serialize their takes
+	services := make([]*ratelimit.Service, replicas)
+	for i := range services {
+		rc := goredis.NewClient(&goredis.Options{Addr: mr.Addr()})
+		t.Cleanup(func() { _ = rc.Close() })
+
+		services[i] = ratelimit.NewService(ratelimit.Configuration{
+			DB:        redis.NewDB(redis.Configuration{Client: rc}),
+			MaxTokens: burst,
+		})
+	}
+
+	var (
+		wg      sync.WaitGroup
+		allowed atomic.Int64
+	)
+	for i := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := services[i%replicas].CheckAndConsume("alice")
+			if err == nil {
+				allowed.Add(1)
+				return
+			}
+			assert.True(t, errors.Is(err, ratelimit.ErrRateLimitExceeded), err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int64(burst), allowed.Load(), "Replicas sharing Redis should consume exactly the burst")
+}
@@ -0,0 +1,108 @@
+// Package sqlite stores the token buckets of rate limits in a SQLite database, so they survive restarts.
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// refill is the SQL expression of the tokens of an existing bucket refilled until @now.
+// Times are stored as unix microseconds, so @rate is in tokens per microsecond.
+const refill = "MIN(@burst, tokens + MAX(@now - refilled_at, 0) * @rate)"
+
+type DB struct {
+	db    *sql.DB
+	table string
+}
+
+type Configuration struct {
+	DB *sql.DB
+	// Table is the name of the table holding the buckets. It is created if it does not exist. Defaults to "ratelimit_buckets".
+	Table string
+}
+
+func NewDB(cfg Configuration) (*DB, error) {
+	if cfg.Table == "" {
+		cfg.Table = "ratelimit_buckets"
+	}
+
+	_, err := cfg.DB.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		client      TEXT PRIMARY KEY,
+		tokens      REAL NOT NULL,
+		refilled_at INTEGER NOT NULL,
+		expires_at  INTEGER
+	)`, cfg.Table))
+	if err != nil {
+		return nil, fmt.Errorf("could not create table %s: %w", cfg.Table, err)
+	}
+
+	return &DB{
+		db:    cfg.DB,
+		table: cfg.Table,
+	}, nil
+}
+
+// Take refills and consumes the tokens with a single upsert, so concurrent requests can't race.
+// The update is skipped if there are not enough tokens, which leaves the bucket as if it had been refilled,
+// because refilling is linear up to the burst.
+func (db *DB) Take(client string, cost int, rate float64, burst int, now time.Time) (bool, int, time.Time, error) {
+	var (
+		tokens float64
+		err    = sql.ErrNoRows
+	)
+	// more than the burst can never be taken, and must not be inserted as new bucket
+	if cost <= burst {
+		err = db.db.QueryRow(fmt.Sprintf(`INSERT INTO %[1]s (client, tokens, refilled_at, expires_at)
+			VALUES (@client, @burst - @cost, @now, @now + CAST(@cost / @rate AS INTEGER))
+			ON CONFLICT (client) DO UPDATE SET
+				tokens = %[2]s - @cost,
+				refilled_at = MAX(refilled_at, @now),
+				expires_at = MAX(refilled_at, @now) + CAST((@burst - (%[2]s - @cost)) / @rate AS INTEGER)
+			WHERE %[2]s >= @cost
+			RETURNING tokens`, db.table, refill),
+			sql.Named("client", client),
+			sql.Named("cost", float64(cost)),
+			sql.Named("burst", float64(burst)),
+			sql.Named("rate", rate/1e6),
+			sql.Named("now", now.UnixMicro()),
+		).Scan(&tokens)
+	}
+
+	allowed := true
+	if errors.Is(err, sql.ErrNoRows) {
+		// not enough tokens, so read how many there are for the result
+		allowed = false
+		err = db.db.QueryRow(fmt.Sprintf("SELECT %s FROM %s WHERE client = @client", refill, db.table),
+			sql.Named("client", client),
+			sql.Named("burst", float64(burst)),
+			sql.Named("rate", rate/1e6),
+			sql.Named("now", now.UnixMicro()),
+		).Scan(&tokens)
+		if errors.Is(err, sql.ErrNoRows) {
+			tokens, err = float64(burst), nil
+		}
+	}
+	if err != nil {
+		return false, 0, time.Time{}, err
+	}
+
+	var reset time.Time
+	if rate > 0 {
+		reset = now.Add(time.Duration((float64(burst) - tokens) / rate * float64(time.Second)))
+	}
+
+	return allowed, int(math.Floor(tokens)), reset, nil
+}
+
+// DeleteExpired deletes all buckets that are full again at now, as they are equal to missing buckets.
+func (db *DB) DeleteExpired(now time.Time) (int64, error) {
+	res, err := db.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE expires_at <= ?", db.table), now.UnixMicro())
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
@@ -0,0 +1,65 @@
+package sqlite_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OliverSchlueter/goutils/containers"
+	"github.com/OliverSchlueter/goutils/ratelimit"
+	"github.com/OliverSchlueter/goutils/ratelimit/database/databasetest"
+	"github.com/OliverSchlueter/goutils/ratelimit/database/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDB(t *testing.T) *sqlite.DB {
+	conn := containers.ConnectSqliteWithOptions(filepath.Join(t.TempDir(), "ratelimit.db"), containers.DefaultSqliteOptions)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	db, err := sqlite.NewDB(sqlite.Configuration{
+		DB: conn,
+	})
+	require.NoError(t, err)
+
+	return db
+}
+
+func TestDB(t *testing.T) {
+	databasetest.Run(t, func(t *testing.T) ratelimit.DB {
+		return newDB(t)
+	})
+}
+
+func TestDB_DeleteExpired(t *testing.T) {
+	db := newDB(t)
+	now := time.Now()
+
+	_, _, _, err := db.Take("alice", 4, 2, 10, now)
+	require.NoError(t, err)
+	_, _, _, err = db.Take("bob", 10, 2, 10, now)
+	require.NoError(t, err)
+
+	// the bucket of alice is full again after 4 / 2 seconds, the one of bob after 10 / 2 seconds
+	deleted, err := db.DeleteExpired(now.Add(3 * time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), deleted)
+
+	allowed, remaining, _, err := db.Take("bob", 1, 2, 10, now.Add(3*time.Second))
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, 5, remaining)
+}
+
+func TestDB_DeleteExpired_WithoutRefill(t *testing.T) {
+	db := newDB(t)
+	now := time.Now()
+
+	_, _, _, err := db.Take("alice", 1, 0, 10, now)
+	require.NoError(t, err)
+
+	// buckets without refill never expire
+	deleted, err := db.DeleteExpired(now.Add(24 * time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), deleted)
+}
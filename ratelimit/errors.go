@@ -2,9 +2,7 @@ package ratelimit
 
 import (
 	"errors"
-	"fmt"
 	"net/http"
-	"time"
 
 	"github.com/OliverSchlueter/goutils/problems"
 )
@@ -13,12 +11,17 @@ var (
 	ErrRateLimitExceeded = errors.New("rate limit exceeded")
 )
 
+var TypeRateLimitExceeded = problems.MustRegister(problems.Definition{
+	Type:        "RateLimitExceeded",
+	Title:       "Rate limit exceeded",
+	Status:      http.StatusTooManyRequests,
+	Description: "The client has exceeded its rate limit. It should wait before sending further requests.",
+})
+
+func init() {
+	problems.RegisterError(ErrRateLimitExceeded, RateLimitExceededProblem)
+}
+
 func RateLimitExceededProblem() *problems.Problem {
-	return &problems.Problem{
-		Type:      "RateLimitExceeded",
-		Title:     "Rate limit exceeded",
-		Detail:    fmt.Sprintf("You have exceeded your rate limit."),
-		Status:    http.StatusTooManyRequests,
-		Timestamp: time.Now(),
-	}
+	return TypeRateLimitExceeded.New("You have exceeded your rate limit.")
 }
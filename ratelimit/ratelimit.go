@@ -1,20 +1,22 @@
 package ratelimit
 
 import (
-	"errors"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/OliverSchlueter/goutils/ratelimit/database/memory"
 	"github.com/OliverSchlueter/goutils/sloki"
 )
 
+// DB stores the token buckets of clients.
 type DB interface {
-	GetTokens(client string) (int, error)
-	SetTokens(client string, tokens int) error
-	GetLastRefill(client string) (time.Time, error)
-	SetLastRefill(client string, t time.Time) error
+	// Take refills the bucket of client by rate tokens per second up to burst tokens and consumes cost tokens, if available.
+	// It returns whether the tokens were consumed, the remaining tokens and when the bucket is full again.
+	// Refilling and consuming is a single atomic operation, so concurrent requests of a client can't over-consume its tokens.
+	Take(client string, cost int, rate float64, burst int, now time.Time) (allowed bool, remaining int, reset time.Time, err error)
 }
 
 type Service struct {
@@ -33,7 +35,7 @@ type Configuration struct {
 
 func NewService(config Configuration) *Service {
 	if config.DB == nil {
-		config.DB = memory.NewDB(config.MaxTokens)
+		config.DB = memory.NewDB()
 	}
 
 	if config.GetIP == nil {
@@ -48,61 +50,88 @@ func NewService(config Configuration) *Service {
 	}
 }
 
-func (s *Service) CheckAndConsume(client string) error {
-	tokens, err := s.db.GetTokens(client)
+// Limit is the state of the bucket of a client after a check.
+type Limit struct {
+	Allowed bool
+	// Limit is the size of the bucket.
+	Limit     int
+	Remaining int
+	// Reset is when the bucket is full again. It is zero if buckets are never refilled.
+	Reset time.Time
+	// RetryAfter is how long the client has to wait until its next request is allowed. It is zero if the request was allowed
+	// or buckets are never refilled.
+	RetryAfter time.Duration
+}
+
+// Check consumes a token of client, if available, and returns the state of its bucket.
+func (s *Service) Check(client string) (Limit, error) {
+	now := time.Now()
+	allowed, remaining, reset, err := s.db.Take(client, 1, s.tokensPerSecond, s.maxTokens, now)
 	if err != nil {
-		return err
+		return Limit{}, err
 	}
 
-	// Refill tokens based on time elapsed
-	lastRefill, err := s.db.GetLastRefill(client)
-	if err != nil {
-		return err
+	limit := Limit{
+		Allowed:   allowed,
+		Limit:     s.maxTokens,
+		Remaining: remaining,
+		Reset:     reset,
+	}
+	if !allowed && s.tokensPerSecond > 0 && !reset.IsZero() {
+		// the bucket is full at reset, so it holds burst - (reset - now) * rate tokens, including partial ones
+		tokens := float64(s.maxTokens) - reset.Sub(now).Seconds()*s.tokensPerSecond
+		limit.RetryAfter = max(time.Duration((1-tokens)/s.tokensPerSecond*float64(time.Second)), 0)
 	}
 
-	now := time.Now()
-	elapsed := now.Sub(lastRefill).Seconds()
+	return limit, nil
+}
 
-	refillTokens := int(elapsed * s.tokensPerSecond)
-	if refillTokens > 0 {
-		tokens += refillTokens
-		if tokens > s.maxTokens {
-			tokens = s.maxTokens
-		}
-		if err := s.db.SetLastRefill(client, now); err != nil {
-			return err
-		}
-		if err := s.db.SetTokens(client, tokens); err != nil {
-			return err
-		}
+func (s *Service) CheckAndConsume(client string) error {
+	limit, err := s.Check(client)
+	if err != nil {
+		return err
 	}
-
-	// Consume a token
-	if tokens <= 0 {
+	if !limit.Allowed {
 		return ErrRateLimitExceeded
 	}
 
-	return s.db.SetTokens(client, tokens-1)
+	return nil
 }
 
 func (s *Service) CheckRequest(r *http.Request, resource string) error {
-	client := s.getIP(r) + "--" + resource
-	return s.CheckAndConsume(client)
+	return s.CheckAndConsume(s.requestClient(r, resource))
 }
 
+func (s *Service) requestClient(r *http.Request, resource string) string {
+	return s.getIP(r) + "--" + resource
+}
+
+// Middleware limits all requests of a client together. It sets the RateLimit-Limit, RateLimit-Remaining and
+// RateLimit-Reset headers, and the Retry-After header on rejected requests.
 func (s *Service) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if err := s.CheckRequest(r, "*"); err != nil {
-			if errors.Is(err, ErrRateLimitExceeded) {
-				RateLimitExceededProblem().WriteToHTTP(w)
-				return
-			}
-
+		limit, err := s.Check(s.requestClient(r, "*"))
+		if err != nil {
 			slog.Error("Rate limit check failed", sloki.WrapError(err))
 			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 			return
 		}
 
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(limit.Remaining))
+		if !limit.Reset.IsZero() {
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil(max(time.Until(limit.Reset), 0).Seconds()))))
+		}
+
+		if !limit.Allowed {
+			problem := RateLimitExceededProblem()
+			if limit.RetryAfter > 0 {
+				problem.WithRetryAfter(limit.RetryAfter)
+			}
+			problem.WriteToHTTP(w)
+			return
+		}
+
 		next.ServeHTTP(w, r)
 	})
 }
@@ -0,0 +1,103 @@
+package ratelimit_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OliverSchlueter/goutils/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestService_CheckAndConsume_Concurrent(t *testing.T) {
+	const (
+		maxTokens = 50
+		clients   = 4
+		requests  = 1000
+	)
+
+	// without refill, every client can consume exactly its max tokens, no matter how many requests race
+	s := ratelimit.NewService(ratelimit.Configuration{
+		TokensPerSecond: 0,
+		MaxTokens:       maxTokens,
+	})
+
+	var (
+		wg       sync.WaitGroup
+		allowed  [clients]atomic.Int64
+		failures atomic.Int64
+	)
+	for i := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			err := s.CheckAndConsume(string(rune('a' + i%clients)))
+			switch {
+			case err == nil:
+				allowed[i%clients].Add(1)
+			case !errors.Is(err, ratelimit.ErrRateLimitExceeded):
+				failures.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Zero(t, failures.Load())
+	for i := range clients {
+		assert.Equal(t, int64(maxTokens), allowed[i].Load())
+	}
+}
+
+func TestService_Middleware(t *testing.T) {
+	s := ratelimit.NewService(ratelimit.Configuration{
+		TokensPerSecond: 0.5,
+		MaxTokens:       1,
+	})
+	handler := s.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Real-IP", "10.0.0.1")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "2", rr.Header().Get("RateLimit-Reset"))
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("Retry-After"))
+}
+
+func TestService_Check(t *testing.T) {
+	s := ratelimit.NewService(ratelimit.Configuration{
+		TokensPerSecond: 2,
+		MaxTokens:       2,
+	})
+
+	for i := 1; i >= 0; i-- {
+		limit, err := s.Check("alice")
+		require.NoError(t, err)
+		assert.True(t, limit.Allowed)
+		assert.Equal(t, 2, limit.Limit)
+		assert.Equal(t, i, limit.Remaining)
+		assert.Zero(t, limit.RetryAfter)
+	}
+
+	limit, err := s.Check("alice")
+	require.NoError(t, err)
+	assert.False(t, limit.Allowed)
+	assert.Equal(t, 0, limit.Remaining)
+	assert.InDelta(t, 500*time.Millisecond, limit.RetryAfter, float64(50*time.Millisecond), "A token should be refilled every 1 / rate seconds")
+	assert.WithinDuration(t, time.Now().Add(time.Second), limit.Reset, 50*time.Millisecond)
+}
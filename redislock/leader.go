@@ -0,0 +1,102 @@
+package redislock
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/OliverSchlueter/goutils/sloki"
+)
+
+// Elector elects a single leader among all replicas competing for the same key.
+type Elector struct {
+	locker           *Locker
+	key              string
+	onBecameLeader   func(ctx context.Context, fence int64)
+	onLostLeadership func()
+	isLeader         atomic.Bool
+}
+
+type ElectorConfiguration struct {
+	Locker *Locker
+	Key    string
+	// OnBecameLeader is called when this replica became the leader.
+	// ctx is cancelled once the leadership is lost, so it can be used to stop the leader's work.
+	// The callback must not block, long-running work should be started in a separate goroutine.
+	OnBecameLeader func(ctx context.Context, fence int64)
+	// OnLostLeadership is called when this replica is not the leader anymore, including on shutdown.
+	OnLostLeadership func()
+}
+
+func NewElector(cfg ElectorConfiguration) *Elector {
+	if cfg.OnBecameLeader == nil {
+		cfg.OnBecameLeader = func(context.Context, int64) {}
+	}
+	if cfg.OnLostLeadership == nil {
+		cfg.OnLostLeadership = func() {}
+	}
+
+	return &Elector{
+		locker:           cfg.Locker,
+		key:              cfg.Key,
+		onBecameLeader:   cfg.OnBecameLeader,
+		onLostLeadership: cfg.OnLostLeadership,
+	}
+}
+
+// IsLeader reports whether this replica is currently the leader.
+func (e *Elector) IsLeader() bool {
+	return e.isLeader.Load()
+}
+
+// Run takes part in the election until ctx is done. The leadership is released on return.
+func (e *Elector) Run(ctx context.Context) error {
+	for {
+		lock, err := e.locker.Acquire(ctx, e.key)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			slog.Warn("Could not acquire leadership", sloki.WrapError(err), slog.String("key", e.key))
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(e.locker.retryInterval):
+			}
+			continue
+		}
+
+		e.lead(ctx, lock)
+
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// lead holds the leadership until the lock is lost or ctx is done.
+func (e *Elector) lead(ctx context.Context, lock *Lock) {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	e.isLeader.Store(true)
+	e.onBecameLeader(leaderCtx, lock.Fence())
+
+	select {
+	case <-lock.Lost():
+		slog.Warn("Lost leadership", slog.String("key", e.key))
+	case <-ctx.Done():
+		releaseCtx, cancelRelease := context.WithTimeout(context.Background(), 5*time.Second)
+		if err := lock.Release(releaseCtx); err != nil && !errors.Is(err, ErrNotHeld) {
+			slog.Warn("Could not release leadership", sloki.WrapError(err), slog.String("key", e.key))
+		}
+		cancelRelease()
+	}
+
+	cancel()
+	e.isLeader.Store(false)
+	e.onLostLeadership()
+}
@@ -0,0 +1,234 @@
+// Package redislock implements a distributed lock and leader election on top of Redis.
+package redislock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/OliverSchlueter/goutils/idgen"
+	"github.com/OliverSchlueter/goutils/sloki"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	ErrNotAcquired = errors.New("lock is held by someone else")
+	ErrNotHeld     = errors.New("lock is not held anymore")
+)
+
+// acquireScript sets the lock if it is free and increments the fencing counter.
+// KEYS[1] = lock key, KEYS[2] = fencing key, ARGV[1] = token, ARGV[2] = ttl in milliseconds
+var acquireScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("INCR", KEYS[2])
+end
+return 0
+`)
+
+// renewScript extends the ttl of the lock if it is still owned by the token.
+// KEYS[1] = lock key, ARGV[1] = token, ARGV[2] = ttl in milliseconds
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// releaseScript deletes the lock if it is still owned by the token.
+// KEYS[1] = lock key, ARGV[1] = token
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type Locker struct {
+	client        redis.Scripter
+	prefix        string
+	ttl           time.Duration
+	retryInterval time.Duration
+}
+
+type Configuration struct {
+	Client redis.Scripter
+	// Prefix is prepended to all lock keys. Defaults to "lock:".
+	// The key of a lock is wrapped in a hash tag ("lock:{key}" and "lock:{key}:fence"), so both keys
+	// of a lock are in the same slot of a Redis Cluster.
+	Prefix string
+	// TTL is the expiry of a lock that is not renewed anymore, e.g. because its owner crashed. Defaults to 10 seconds.
+	// Locks are renewed automatically every TTL / 3. A lock is reported as lost (see Lock.Lost) as soon as a renewal fails,
+	// and at the latest TTL * 2/3 after its last renewal, so its holder can stop before someone else acquires it.
+	TTL time.Duration
+	// RetryInterval is the wait time between attempts of Acquire. Defaults to 100 milliseconds.
+	RetryInterval time.Duration
+}
+
+func NewLocker(cfg Configuration) *Locker {
+	if cfg.Prefix == "" {
+		cfg.Prefix = "lock:"
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = 10 * time.Second
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = 100 * time.Millisecond
+	}
+
+	return &Locker{
+		client:        cfg.Client,
+		prefix:        cfg.Prefix,
+		ttl:           cfg.TTL,
+		retryInterval: cfg.RetryInterval,
+	}
+}
+
+// TryAcquire acquires the lock for key, or returns ErrNotAcquired if it is held by someone else.
+func (l *Locker) TryAcquire(ctx context.Context, key string) (*Lock, error) {
+	token := idgen.GenerateID(24)
+	lockKey := l.prefix + "{" + key + "}"
+	acquired := time.Now()
+
+	fence, err := acquireScript.Run(ctx, l.client, []string{lockKey, lockKey + ":fence"}, token, l.ttl.Milliseconds()).Int64()
+	if err != nil {
+		return nil, fmt.Errorf("could not acquire lock: %w", err)
+	}
+	if fence == 0 {
+		return nil, ErrNotAcquired
+	}
+
+	renewCtx, cancel := context.WithCancel(context.Background())
+	lock := &Lock{
+		locker: l,
+		key:    lockKey,
+		token:  token,
+		fence:  fence,
+		lost:   make(chan struct{}),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go lock.renew(renewCtx, acquired)
+
+	return lock, nil
+}
+
+// Acquire waits until the lock for key is acquired or ctx is done.
+func (l *Locker) Acquire(ctx context.Context, key string) (*Lock, error) {
+	ticker := time.NewTicker(l.retryInterval)
+	defer ticker.Stop()
+
+	for {
+		lock, err := l.TryAcquire(ctx, key)
+		if !errors.Is(err, ErrNotAcquired) {
+			return lock, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Lock is an acquired lock that is renewed in the background until it is released.
+type Lock struct {
+	locker *Locker
+	key    string
+	token  string
+	fence  int64
+
+	lostOnce sync.Once
+	lost     chan struct{}
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// Fence returns the fencing token of the lock.
+// It is strictly increasing for every acquisition of the same key, so resources protected by the lock
+// can reject writes carrying a token lower than the highest one they have seen.
+func (lock *Lock) Fence() int64 {
+	return lock.fence
+}
+
+// Lost returns a channel that is closed when the lock could not be renewed and may be held by someone else now.
+func (lock *Lock) Lost() <-chan struct{} {
+	return lock.lost
+}
+
+// Release stops the renewal and deletes the lock.
+// It returns ErrNotHeld if the lock expired or was acquired by someone else in the meantime.
+func (lock *Lock) Release(ctx context.Context) error {
+	lock.cancel()
+	<-lock.done
+
+	released, err := releaseScript.Run(ctx, lock.locker.client, []string{lock.key}, lock.token).Int64()
+	if err != nil {
+		return fmt.Errorf("could not release lock: %w", err)
+	}
+	if released == 0 {
+		lock.markLost()
+		return ErrNotHeld
+	}
+
+	return nil
+}
+
+func (lock *Lock) markLost() {
+	lock.lostOnce.Do(func() {
+		close(lock.lost)
+	})
+}
+
+// renew extends the lock every ttl / 3 until ctx is done.
+// The lock expires ttl after the start of the last successful renewal, so it is marked as lost a safety margin
+// of ttl / 3 before that, and every renewal is aborted after ttl / 6.
+func (lock *Lock) renew(ctx context.Context, acquired time.Time) {
+	defer close(lock.done)
+
+	ttl := lock.locker.ttl
+	safetyMargin := ttl / 3
+	renewTimeout := ttl / 6
+
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+
+	deadline := acquired.Add(ttl - safetyMargin)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if time.Now().After(deadline) {
+			slog.Warn("Lock was not renewed in time", slog.String("key", lock.key))
+			lock.markLost()
+			return
+		}
+
+		start := time.Now()
+		renewCtx, cancel := context.WithTimeout(ctx, renewTimeout)
+		renewed, err := renewScript.Run(renewCtx, lock.locker.client, []string{lock.key}, lock.token, ttl.Milliseconds()).Int64()
+		cancel()
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+
+			// the lock may expire before the next renewal, so it must not be relied on anymore
+			slog.Warn("Could not renew lock", sloki.WrapError(err), slog.String("key", lock.key))
+			lock.markLost()
+			return
+		}
+		if renewed != 1 {
+			lock.markLost()
+			return
+		}
+
+		deadline = start.Add(ttl - safetyMargin)
+	}
+}
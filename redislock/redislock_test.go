@@ -0,0 +1,202 @@
+package redislock_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OliverSchlueter/goutils/redislock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLocker(t *testing.T, ttl time.Duration) (*redislock.Locker, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	rc := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rc.Close() })
+
+	return redislock.NewLocker(redislock.Configuration{
+		Client:        rc,
+		TTL:           ttl,
+		RetryInterval: 5 * time.Millisecond,
+	}), mr
+}
+
+func TestLocker_TryAcquire(t *testing.T) {
+	locker, mr := newLocker(t, time.Second)
+	ctx := context.Background()
+
+	lock, err := locker.TryAcquire(ctx, "job")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), lock.Fence())
+	assert.True(t, mr.Exists("lock:{job}"))
+
+	_, err = locker.TryAcquire(ctx, "job")
+	assert.ErrorIs(t, err, redislock.ErrNotAcquired)
+
+	require.NoError(t, lock.Release(ctx))
+	assert.False(t, mr.Exists("lock:{job}"))
+
+	lock, err = locker.TryAcquire(ctx, "job")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), lock.Fence())
+	require.NoError(t, lock.Release(ctx))
+}
+
+func TestLocker_Acquire(t *testing.T) {
+	locker, _ := newLocker(t, time.Second)
+	ctx := context.Background()
+
+	lock, err := locker.TryAcquire(ctx, "job")
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_ = lock.Release(ctx)
+	}()
+
+	other, err := locker.Acquire(ctx, "job")
+	require.NoError(t, err)
+	assert.Greater(t, other.Fence(), lock.Fence())
+	require.NoError(t, other.Release(ctx))
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	held, err := locker.TryAcquire(ctx, "job")
+	require.NoError(t, err)
+	_, err = locker.Acquire(timeoutCtx, "job")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	require.NoError(t, held.Release(ctx))
+}
+
+func TestLock_Renewal(t *testing.T) {
+	locker, mr := newLocker(t, 60*time.Millisecond)
+	ctx := context.Background()
+
+	lock, err := locker.TryAcquire(ctx, "job")
+	require.NoError(t, err)
+
+	// the ttl is reset by the renewal
+	time.Sleep(50 * time.Millisecond)
+	assert.Greater(t, mr.TTL("lock:{job}"), 30*time.Millisecond)
+
+	select {
+	case <-lock.Lost():
+		t.Fatal("lock should not be lost")
+	default:
+	}
+
+	require.NoError(t, lock.Release(ctx))
+}
+
+func TestLock_Lost(t *testing.T) {
+	locker, mr := newLocker(t, 30*time.Millisecond)
+	ctx := context.Background()
+
+	lock, err := locker.TryAcquire(ctx, "job")
+	require.NoError(t, err)
+
+	// someone else took over the lock
+	require.NoError(t, mr.Set("lock:{job}", "other"))
+
+	select {
+	case <-lock.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the lock to be lost")
+	}
+
+	// releasing must not delete the lock of the new owner
+	assert.ErrorIs(t, lock.Release(ctx), redislock.ErrNotHeld)
+	got, err := mr.Get("lock:{job}")
+	require.NoError(t, err)
+	assert.Equal(t, "other", got)
+}
+
+func TestElector(t *testing.T) {
+	locker, mr := newLocker(t, 30*time.Millisecond)
+
+	var leaders atomic.Int32
+	var elected, lost atomic.Int32
+	newElector := func() *redislock.Elector {
+		return redislock.NewElector(redislock.ElectorConfiguration{
+			Locker: locker,
+			Key:    "leader",
+			OnBecameLeader: func(ctx context.Context, fence int64) {
+				elected.Add(1)
+				leaders.Add(1)
+				go func() {
+					<-ctx.Done()
+					leaders.Add(-1)
+				}()
+			},
+			OnLostLeadership: func() {
+				lost.Add(1)
+			},
+		})
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+
+	e1, e2 := newElector(), newElector()
+	done1 := make(chan struct{})
+	go func() {
+		assert.NoError(t, e1.Run(ctx1))
+		close(done1)
+	}()
+	require.Eventually(t, e1.IsLeader, time.Second, time.Millisecond)
+
+	go func() {
+		assert.NoError(t, e2.Run(ctx2))
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, e1.IsLeader())
+	assert.False(t, e2.IsLeader())
+	assert.Equal(t, int32(1), leaders.Load())
+
+	// shutting down the leader hands the leadership over
+	cancel1()
+	<-done1
+	assert.False(t, e1.IsLeader())
+	require.Eventually(t, e2.IsLeader, time.Second, time.Millisecond)
+	assert.Equal(t, int32(2), elected.Load())
+	assert.Equal(t, int32(1), lost.Load())
+
+	// losing the lock ends the leadership and triggers a new election
+	require.NoError(t, mr.Set("lock:{leader}", "other"))
+	require.Eventually(t, func() bool {
+		return lost.Load() == 2
+	}, time.Second, time.Millisecond)
+
+	mr.Del("lock:{leader}")
+	require.Eventually(t, e2.IsLeader, time.Second, time.Millisecond)
+	assert.Equal(t, int32(3), elected.Load())
+}
+
+func TestLock_LostOnRenewalError(t *testing.T) {
+	locker, mr := newLocker(t, 300*time.Millisecond)
+	ctx := context.Background()
+
+	lock, err := locker.TryAcquire(ctx, "job")
+	require.NoError(t, err)
+	assert.True(t, mr.Exists("lock:{job}:fence"), "Both keys should share the hash tag of the lock")
+
+	acquired := time.Now()
+	mr.SetError("connection reset")
+
+	select {
+	case <-lock.Lost():
+		// the first failed renewal after ttl / 3 must signal the loss, long before the lock expires
+		assert.Less(t, time.Since(acquired), 200*time.Millisecond)
+	case <-time.After(time.Second):
+		t.Fatal("lock was not marked as lost")
+	}
+
+	mr.SetError("")
+	_ = lock.Release(ctx)
+}